@@ -0,0 +1,65 @@
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+type allTarget struct {
+	*multiTarget
+
+	mu         sync.Mutex
+	baselines  [][]byte
+	generation uint64
+}
+
+var _ Watched = &allTarget{}
+var _ ErrorClassifier = &allTarget{}
+
+// All constructs a Watch that, unlike Multi, only reports an update once
+// every one of the provided watches' targets has changed since the last
+// update it reported, e.g. for a certificate and key that must both be
+// rotated before reloading. A target that changes and then returns to its
+// previous content hasn't changed. As with Multi the children are checked by
+// the returned Watch rather than polled independently.
+//
+// Every check of the returned Watch's target counts towards whether all the
+// targets have changed, so unlike with other watches calling Explain, or
+// Snapshot before anything else has checked, moves the contents that later
+// changes are judged against.
+func All(watches ...Watch) Watch {
+	return New(&allTarget{
+		multiTarget: &multiTarget{watches},
+		baselines:   make([][]byte, len(watches)),
+	})
+}
+
+// Content is the number of times every target has changed together, which
+// only moves once all of them have.
+func (at *allTarget) Content() ([]byte, error) {
+	digests := make([][]byte, len(at.watches))
+	for i, w := range at.watches {
+		hash, err := digestOf(w)
+		if err != nil {
+			return nil, childError{i, err}
+		}
+		digests[i] = hash
+	}
+
+	at.mu.Lock()
+	defer at.mu.Unlock()
+
+	all := true
+	for i, digest := range digests {
+		if bytes.Equal(digest, at.baselines[i]) {
+			all = false
+			break
+		}
+	}
+	if all {
+		at.baselines = digests
+		at.generation++
+	}
+	return []byte(fmt.Sprint(at.generation)), nil
+}
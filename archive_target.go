@@ -0,0 +1,136 @@
+package watch
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+type watchedArchive struct {
+	path     string
+	failOpen bool
+}
+
+var _ Watched = &watchedArchive{}
+var _ Identifier = &watchedArchive{}
+
+// ArchiveTarget constructs a Watched wrapper for the logical contents of a
+// zip, tar, or gzip compressed tar archive at path; the format is detected
+// from the file's content. Its content lists every entry's name and a hash of
+// its data, sorted by name, so that archive metadata such as modification
+// times, entry order, and compression settings are ignored and an archive
+// rebuilt from identical files is not seen as a change. A corrupt archive is
+// an error.
+func ArchiveTarget(path string, failOpen bool) Watched {
+	return watchedArchive{path, failOpen}
+}
+
+// archiveEntry is what an archive's content is made up of for each entry.
+type archiveEntry struct {
+	name string
+	hash []byte
+}
+
+var zipMagic = []byte("PK\x03\x04")
+
+func (wa watchedArchive) FailOpen() bool   { return wa.failOpen }
+func (wa watchedArchive) Identity() string { return wa.path }
+func (wa watchedArchive) Content() ([]byte, error) {
+	f, err := os.Open(wa.path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read archive: %w", missing(err))
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	magic, err := r.Peek(len(zipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("Unable to read archive %v: %w", wa.path, err)
+	}
+
+	var entries []archiveEntry
+	switch {
+	case bytes.Equal(magic, zipMagic):
+		entries, err = zipEntries(f)
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, zerr := gzip.NewReader(r)
+		if zerr != nil {
+			return nil, fmt.Errorf("Unable to decompress archive %v: %w", wa.path, zerr)
+		}
+		defer zr.Close()
+		entries, err = tarEntries(zr)
+	default:
+		entries, err = tarEntries(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read archive %v: %w", wa.path, err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "%s\x00%x\n", e.name, e.hash)
+	}
+	return buf.Bytes(), nil
+}
+
+func zipEntries(f *os.File) ([]archiveEntry, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []archiveEntry
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{zf.Name, md5Hash(data)})
+	}
+	return entries, nil
+}
+
+func tarEntries(r io.Reader) ([]archiveEntry, error) {
+	tr := tar.NewReader(r)
+
+	var entries []archiveEntry
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var data []byte
+		switch hdr.Typeflag {
+		case tar.TypeSymlink, tar.TypeLink:
+			// a link's identity is where it points
+			data = []byte(hdr.Linkname)
+		default:
+			if data, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, archiveEntry{hdr.Name, md5Hash(data)})
+	}
+}
@@ -0,0 +1,53 @@
+package watch
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time for a watch (c.f. WithClock), allowing tests to
+// drive its loops without sleeping. The watchtest package provides a fake.
+type Clock interface {
+	Now() time.Time
+
+	// NewTimer returns a Timer that fires once d has elapsed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer made by a Clock. It behaves like a *time.Timer: once Stop
+// or Reset returns no stale value is received from C.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// realClock is the Clock used by default.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type clockKey struct{}
+
+// withClock returns a context carrying clock to the targets checked with it.
+func withClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockKey{}, clock)
+}
+
+// clockFrom returns the clock of the watch checking a target with ctx (c.f.
+// withClock), or the system clock if there isn't one.
+func clockFrom(ctx context.Context) Clock {
+	if clock, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return clock
+	}
+	return realClock{}
+}
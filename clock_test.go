@@ -0,0 +1,196 @@
+package watch_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/falun/watch"
+	"github.com/falun/watch/watchtest"
+)
+
+// The tests below drive watch loops with a fake clock: each Advance fires
+// whatever timers come due and BlockUntil waits for the loop to have
+// scheduled its next timers, which it does once it has finished reacting.
+
+const tick = time.Second
+
+// received reports whether ch delivers a value within a second of real time.
+func received(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+// pending reports whether ch has a value ready right away.
+func pending(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestDebouncedWithClock(t *testing.T) {
+	clock := watchtest.NewClock(time.Now())
+	fake := watchtest.NewFake([]byte("a"))
+	ch, cancel := watch.New(fake, watch.WithClock(clock)).OnIntervalDebounced(tick, 3*tick)
+	defer cancel()
+
+	// the loop's poll timer is all that is scheduled until a change starts a
+	// quiet period, which is at most one more
+	clock.BlockUntil(1)
+	clock.Advance(tick) // first check, starting the quiet period
+	clock.BlockUntil(2)
+
+	fake.SetContent([]byte("b"))
+	clock.Advance(tick) // a change restarts the quiet period
+	clock.BlockUntilScheduled(3 * tick)
+	clock.BlockUntil(2)
+
+	for i := 0; i < 2; i++ {
+		clock.Advance(tick)
+		clock.BlockUntil(2)
+		if pending(ch) {
+			t.Fatalf("emitted %v after the last change, before going quiet", time.Duration(i+1)*tick)
+		}
+	}
+
+	clock.Advance(tick) // quiet for long enough
+	if !received(ch) {
+		t.Fatal("didn't emit once quiet")
+	}
+	clock.BlockUntil(1)
+
+	for i := 0; i < 4; i++ {
+		clock.Advance(tick)
+		clock.BlockUntil(1)
+	}
+	if pending(ch) {
+		t.Error("burst of changes emitted more than once")
+	}
+}
+
+func TestThrottledWithClock(t *testing.T) {
+	clock := watchtest.NewClock(time.Now())
+	fake := watchtest.NewFake([]byte("a"))
+	ch, cancel := watch.New(fake, watch.WithClock(clock)).OnIntervalThrottled(tick, 5*tick)
+	defer cancel()
+
+	clock.BlockUntil(1)
+	clock.Advance(tick) // first change is emitted right away
+	if !received(ch) {
+		t.Fatal("first change not emitted")
+	}
+	clock.BlockUntil(1)
+
+	// changes within the gap are held until it has elapsed
+	for _, content := range []string{"b", "c"} {
+		fake.SetContent([]byte(content))
+		clock.Advance(tick)
+		clock.BlockUntil(2)
+	}
+	for i := 0; i < 2; i++ {
+		clock.Advance(tick)
+		clock.BlockUntil(2)
+	}
+	if pending(ch) {
+		t.Fatal("emitted within the gap")
+	}
+
+	clock.Advance(tick) // end of the gap
+	if !received(ch) {
+		t.Fatal("changes within the gap not emitted once it elapsed")
+	}
+	clock.BlockUntil(1)
+
+	for i := 0; i < 3; i++ {
+		clock.Advance(tick)
+		clock.BlockUntil(1)
+	}
+	if pending(ch) {
+		t.Error("changes within the gap emitted more than once")
+	}
+}
+
+func TestCooldownWithClock(t *testing.T) {
+	clock := watchtest.NewClock(time.Now())
+	fake := watchtest.NewFake([]byte("a"))
+	w := watch.New(fake, watch.WithClock(clock))
+	ch, cancel := w.OnIntervalWithOpts(tick, watch.OnIntervalOpts{Cooldown: 3 * tick, Coalesce: true})
+	defer cancel()
+
+	clock.BlockUntil(1)
+	clock.Advance(tick)
+	if !received(ch) {
+		t.Fatal("first change not emitted")
+	}
+	clock.BlockUntil(1) // the cooldown
+
+	// changed during the cooldown, so taken as the baseline
+	fake.SetContent([]byte("b"))
+	clock.Advance(3 * tick)
+	clock.BlockUntil(1)
+	clock.Advance(tick)
+	clock.BlockUntil(1)
+	if pending(ch) {
+		t.Fatal("change made during the cooldown emitted")
+	}
+	if changes := w.Stats().Changes; changes != 1 {
+		// the end of the cooldown only takes a baseline
+		t.Errorf("Stats().Changes = %d after one emitted change, want 1", changes)
+	}
+
+	fake.SetContent([]byte("c"))
+	clock.Advance(tick)
+	clock.BlockUntil(1)
+	if !pending(ch) {
+		t.Error("change after the cooldown not emitted")
+	}
+}
+
+func TestBackoffWithClock(t *testing.T) {
+	clock := watchtest.NewClock(time.Now())
+	fake := watchtest.NewFake(nil)
+	fake.SetError(errors.New("unavailable"))
+	w := watch.New(fake, watch.WithClock(clock))
+	ch, cancel := w.OnIntervalWithOpts(tick, watch.OnIntervalOpts{
+		BackoffMultiplier: 2,
+		BackoffMax:        4 * tick,
+		Coalesce:          true,
+	})
+	defer cancel()
+
+	// advance step by step, asserting how many checks have been made
+	checks := func(at time.Duration, want int) {
+		t.Helper()
+		clock.Advance(tick)
+		clock.BlockUntil(1)
+		if got := fake.Calls(); got != want {
+			t.Fatalf("%d checks after %v, want %d", got, at, want)
+		}
+	}
+
+	clock.BlockUntil(1)
+	checks(1*tick, 1) // fails; waits 2s
+	checks(2*tick, 1)
+	checks(3*tick, 2) // fails; waits 4s
+	checks(4*tick, 2)
+	checks(5*tick, 2)
+	checks(6*tick, 2)
+	checks(7*tick, 3) // fails; capped at 4s
+	fake.SetContent([]byte("recovered"))
+	checks(8*tick, 3)
+	checks(9*tick, 3)
+	checks(10*tick, 3)
+	checks(11*tick, 4) // succeeds; back to 1s
+	checks(12*tick, 5)
+
+	if !pending(ch) {
+		t.Error("recovered content not emitted")
+	}
+}
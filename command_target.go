@@ -0,0 +1,90 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// DefaultCommandTimeout is the execution timeout used by CommandTarget when
+// none is provided.
+const DefaultCommandTimeout = 30 * time.Second
+
+// CommandOpts controls optional behavior of a CommandTarget.
+type CommandOpts struct {
+	// Timeout bounds each run of the command; a command still running when it
+	// expires is killed. Zero means DefaultCommandTimeout.
+	Timeout time.Duration
+
+	// StdoutOnly watches only what the command writes to stdout rather than
+	// its combined stdout and stderr, so diagnostics on stderr aren't treated
+	// as changes.
+	StdoutOnly bool
+
+	// IncludeExitCode makes the exit code part of the content, so a command
+	// that starts failing is seen as a change even if its output is the same.
+	// A non-zero exit is then not treated as an error.
+	IncludeExitCode bool
+}
+
+type watchedCommand struct {
+	name     string
+	args     []string
+	failOpen bool
+	opts     CommandOpts
+}
+
+var _ Watched = &watchedCommand{}
+var _ Identifier = &watchedCommand{}
+
+// CommandTarget constructs a Watched wrapper around the output of running a
+// command. By default the combined stdout and stderr are watched and a
+// non-zero exit status is treated as an error.
+func CommandTarget(name string, args []string, failOpen bool, opts ...CommandOpts) Watched {
+	var o CommandOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Timeout == 0 {
+		o.Timeout = DefaultCommandTimeout
+	}
+
+	return watchedCommand{name, args, failOpen, o}
+}
+
+func (wc watchedCommand) FailOpen() bool { return wc.failOpen }
+func (wc watchedCommand) Identity() string {
+	return exec.Command(wc.name, wc.args...).String()
+}
+func (wc watchedCommand) Content() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), wc.opts.Timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, wc.name, wc.args...)
+	cmd.Stdout = &output
+	if !wc.opts.StdoutOnly {
+		cmd.Stderr = &output
+	}
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("Command %v timed out after %v: %w", wc.Identity(), wc.opts.Timeout, ctx.Err())
+	}
+
+	var exitErr *exec.ExitError
+	if wc.opts.IncludeExitCode && errors.As(err, &exitErr) {
+		fmt.Fprintf(&output, "\nexit status %d\n", exitErr.ExitCode())
+		return output.Bytes(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to run command %v: %w", wc.Identity(), err)
+	}
+	if wc.opts.IncludeExitCode {
+		output.WriteString("\nexit status 0\n")
+	}
+	return output.Bytes(), nil
+}
@@ -0,0 +1,89 @@
+package watch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+type watchedConfigMap struct {
+	dir      string
+	failOpen bool
+}
+
+var _ Watched = &watchedConfigMap{}
+var _ Identifier = &watchedConfigMap{}
+
+// ConfigMapTarget constructs a Watched wrapper for a Kubernetes ConfigMap or
+// Secret volume mounted at dir. The kubelet updates such volumes by writing a
+// new timestamped directory and atomically repointing the dir/..data symlink
+// at it, so the files are read from wherever ..data resolves to. This gives a
+// consistent view of every key and each swap is seen as a single change. Each
+// file's content is prefixed with its path and length, as with FilesTarget.
+func ConfigMapTarget(dir string, failOpen bool) Watched {
+	return watchedConfigMap{dir, failOpen}
+}
+
+func (wc watchedConfigMap) FailOpen() bool   { return wc.failOpen }
+func (wc watchedConfigMap) Identity() string { return wc.dir }
+func (wc watchedConfigMap) Content() ([]byte, error) {
+	content, err := wc.read()
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, errDataSwapped) {
+		// most likely the old data directory was removed after the symlink
+		// was swapped while we were reading it; the new one is complete
+		content, err = wc.read()
+	}
+	return content, err
+}
+
+// errDataSwapped is returned by read when ..data was repointed while reading,
+// in which case what was read may be missing keys that were being removed
+var errDataSwapped = errors.New("config map data was swapped while reading")
+
+func (wc watchedConfigMap) data() (string, error) {
+	data, err := filepath.EvalSymlinks(filepath.Join(wc.dir, "..data"))
+	if err != nil {
+		return "", fmt.Errorf("Unable to resolve config map data: %w", missing(err))
+	}
+	return data, nil
+}
+
+func (wc watchedConfigMap) read() ([]byte, error) {
+	data, err := wc.data()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = filepath.Walk(data, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(data, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "%s\x00%d\n", filepath.ToSlash(rel), len(content))
+		buf.Write(content)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read config map data: %w", missing(err))
+	}
+	if now, err := wc.data(); err != nil || now != data {
+		return nil, fmt.Errorf("Unable to read config map data in %v: %w", wc.dir, errDataSwapped)
+	}
+	return buf.Bytes(), nil
+}
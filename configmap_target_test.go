@@ -0,0 +1,134 @@
+package watch_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/falun/watch"
+)
+
+// configMap lays out a mounted config map volume the way the kubelet does:
+// keys live in a timestamped directory that dir/..data points at.
+type configMap struct {
+	t   *testing.T
+	dir string
+	ts  string
+	gen int
+}
+
+// update writes keys to a new timestamped directory, atomically repoints
+// ..data at it and removes the old one.
+func (cm *configMap) update(keys map[string]string) {
+	cm.gen++
+	ts := fmt.Sprintf("..2024_01_01_00_00_%02d.%d", cm.gen%60, cm.gen)
+	if err := os.Mkdir(filepath.Join(cm.dir, ts), 0o755); err != nil {
+		cm.t.Fatal(err)
+	}
+	for key, value := range keys {
+		if err := os.WriteFile(filepath.Join(cm.dir, ts, key), []byte(value), 0o644); err != nil {
+			cm.t.Fatal(err)
+		}
+	}
+
+	tmp := filepath.Join(cm.dir, "..data_tmp")
+	if err := os.Symlink(ts, tmp); err != nil {
+		cm.t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(cm.dir, "..data")); err != nil {
+		cm.t.Fatal(err)
+	}
+	if cm.ts != "" {
+		if err := os.RemoveAll(filepath.Join(cm.dir, cm.ts)); err != nil {
+			cm.t.Fatal(err)
+		}
+	}
+	cm.ts = ts
+}
+
+// keys returns n keys whose values all belong to generation gen.
+func keys(n, gen int) map[string]string {
+	keys := map[string]string{}
+	for i := 0; i < n; i++ {
+		keys[fmt.Sprintf("key%02d", i)] = fmt.Sprintf("generation %d", gen)
+	}
+	return keys
+}
+
+func TestConfigMapTargetSwap(t *testing.T) {
+	cm := &configMap{t: t, dir: t.TempDir()}
+	cm.update(keys(3, 0))
+
+	w := watch.New(watch.ConfigMapTarget(cm.dir, false))
+	if _, err := w.Updated(); err != nil {
+		t.Fatal(err)
+	}
+
+	cm.update(keys(3, 1))
+	changed, err := w.Updated()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Error("swapping ..data not reported as a change")
+	}
+	if changed, err := w.Updated(); err != nil || changed {
+		t.Errorf("Updated() = %v, %v after a single swap; want false, nil", changed, err)
+	}
+}
+
+func TestConfigMapTargetReadDuringSwap(t *testing.T) {
+	const n, swaps = 20, 30
+
+	cm := &configMap{t: t, dir: t.TempDir()}
+	cm.update(keys(n, 0))
+	target := watch.ConfigMapTarget(cm.dir, false)
+
+	// the content of every generation, a read must see one of them whole
+	valid := map[string]bool{}
+	for gen := 0; gen <= swaps; gen++ {
+		dir := t.TempDir()
+		ref := &configMap{t: t, dir: dir}
+		ref.update(keys(n, gen))
+		content, err := watch.ConfigMapTarget(dir, false).Content()
+		if err != nil {
+			t.Fatal(err)
+		}
+		valid[string(content)] = true
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			content, err := target.Content()
+			if err != nil {
+				t.Errorf("read during swap failed: %v", err)
+				return
+			}
+			if !valid[string(content)] {
+				t.Error("read during swap saw a mix of generations")
+				return
+			}
+		}
+	}()
+
+	for gen := 1; gen <= swaps; gen++ {
+		cm.update(keys(n, gen))
+		// the retry covers a single swap per read; the kubelet swaps far
+		// less often than this
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,170 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// The decorators below each wrap a Watched to layer a behavior on top of it,
+// so a pipeline can be assembled and tested a target at a time rather than
+// all through Options to New. A decorated target keeps the fail-open policy,
+// including any ErrorClassifier, and identity of the target it wraps, and can
+// be cancelled (c.f. ContextWatched) if that target can.
+
+// decorated forwards the fail-open policy, identity and content of the target
+// it wraps.
+type decorated struct {
+	Watched
+}
+
+var _ decorator = &decorated{}
+
+func (d decorated) FailOpenOn(err error) bool { return failOpen(d.Watched, err) }
+func (d decorated) Identity() string          { return identity(d.Watched) }
+func (d decorated) contentCtx(ctx context.Context) ([]byte, error) {
+	return contentOf(ctx, d.Watched)
+}
+
+// decorator is a target wrapping another, which it builds on decorated to do.
+type decorator interface {
+	Watched
+	ErrorClassifier
+	Identifier
+
+	// contentCtx fetches the content, passing ctx on to the wrapped target
+	contentCtx(ctx context.Context) ([]byte, error)
+}
+
+// cancellable exposes a decorator's contentCtx as ContentCtx.
+type cancellable struct {
+	decorator
+}
+
+var _ ContextWatched = &cancellable{}
+
+func (c cancellable) ContentCtx(ctx context.Context) ([]byte, error) { return c.contentCtx(ctx) }
+
+// decorate returns d, which wraps target, as a ContextWatched if target is one
+// so that checks of it can still be cancelled.
+func decorate(d decorator, target Watched) Watched {
+	if _, ok := target.(ContextWatched); ok {
+		return cancellable{d}
+	}
+	return d
+}
+
+// contentOf fetches target's content, passing it ctx if it is a
+// ContextWatched. Other targets are left to finish in the background once ctx
+// is done, as a watch does with them.
+func contentOf(ctx context.Context, target Watched) ([]byte, error) {
+	if cw, ok := target.(ContextWatched); ok {
+		return cw.ContentCtx(ctx)
+	}
+	if ctx.Done() == nil {
+		return target.Content()
+	}
+
+	type result struct {
+		content []byte
+		err     error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		content, err := target.Content()
+		ch <- result{content, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.content, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type filteredTarget struct {
+	decorated
+	filter func([]byte) []byte
+}
+
+// FilterTarget wraps target so that its content is passed through filter, as
+// with WithContentFilter.
+func FilterTarget(target Watched, filter func([]byte) []byte) Watched {
+	return decorate(filteredTarget{decorated{target}, filter}, target)
+}
+
+func (ft filteredTarget) Content() ([]byte, error) {
+	return ft.contentCtx(context.Background())
+}
+
+func (ft filteredTarget) contentCtx(ctx context.Context) ([]byte, error) {
+	content, err := ft.decorated.contentCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ft.filter(content), nil
+}
+
+type normalizedTarget struct {
+	decorated
+	normalize func([]byte) ([]byte, error)
+}
+
+// NormalizeTarget wraps target so that its content is passed through
+// normalize, as with WithNormalizer. Unlike WithNormalizer the normalized
+// content is what the watch reports, e.g. from OnIntervalContent.
+func NormalizeTarget(target Watched, normalize func([]byte) ([]byte, error)) Watched {
+	return decorate(normalizedTarget{decorated{target}, normalize}, target)
+}
+
+func (nt normalizedTarget) Content() ([]byte, error) {
+	return nt.contentCtx(context.Background())
+}
+
+func (nt normalizedTarget) contentCtx(ctx context.Context) ([]byte, error) {
+	content, err := nt.decorated.contentCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if content, err = nt.normalize(content); err != nil {
+		return nil, fmt.Errorf("Unable to normalize target content: %w", err)
+	}
+	return content, nil
+}
+
+type retriedTarget struct {
+	decorated
+	attempts int
+	delay    time.Duration
+}
+
+// RetryTarget wraps target so that fetching its content is tried up to
+// attempts times in all, delay apart, as with WithRetry. The delays are timed
+// by the watch's clock (c.f. WithClock) and, like the attempts, cut short when
+// the check is cancelled, e.g. by a check timeout or Stop.
+func RetryTarget(target Watched, attempts int, delay time.Duration) Watched {
+	// always cancellable since waiting between attempts is
+	return cancellable{retriedTarget{decorated{target}, attempts, delay}}
+}
+
+func (rt retriedTarget) Content() ([]byte, error) {
+	return rt.contentCtx(context.Background())
+}
+
+func (rt retriedTarget) contentCtx(ctx context.Context) ([]byte, error) {
+	clock := clockFrom(ctx)
+
+	content, err := rt.decorated.contentCtx(ctx)
+	for attempt := 1; err != nil && attempt < rt.attempts; attempt++ {
+		timer := clock.NewTimer(rt.delay)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
+		content, err = rt.decorated.contentCtx(ctx)
+	}
+	return content, err
+}
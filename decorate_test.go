@@ -0,0 +1,159 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/falun/watch"
+	"github.com/falun/watch/watchtest"
+)
+
+// blockingTarget is a ContextWatched whose checks wait for ctx to be done.
+type blockingTarget struct{}
+
+func (blockingTarget) FailOpen() bool           { return false }
+func (blockingTarget) Identity() string         { return "blocking" }
+func (blockingTarget) Content() ([]byte, error) { select {} }
+func (blockingTarget) ContentCtx(ctx context.Context) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestDecoratorsForwardContext(t *testing.T) {
+	for name, target := range map[string]watch.Watched{
+		"FilterTarget":    watch.FilterTarget(blockingTarget{}, func(b []byte) []byte { return b }),
+		"NormalizeTarget": watch.NormalizeTarget(blockingTarget{}, func(b []byte) ([]byte, error) { return b, nil }),
+		"RetryTarget":     watch.RetryTarget(blockingTarget{}, 3, time.Hour),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if id, ok := target.(watch.Identifier); !ok || id.Identity() != "blocking" {
+				t.Error("identity of the wrapped target not forwarded")
+			}
+			if _, ok := target.(watch.ContextWatched); !ok {
+				t.Fatal("wrapping a ContextWatched isn't one")
+			}
+
+			w := watch.New(target, watch.WithCheckTimeout(10*time.Millisecond))
+			if _, err := w.Updated(); !errors.Is(err, watch.ErrCheckTimeout) {
+				t.Errorf("Updated() error = %v, want ErrCheckTimeout", err)
+			}
+		})
+	}
+}
+
+func TestFilterTargetOfPlainTarget(t *testing.T) {
+	target := watch.FilterTarget(watchtest.NewFake([]byte("x")), func(b []byte) []byte { return b })
+	if _, ok := target.(watch.ContextWatched); ok {
+		t.Error("wrapping a plain target made it a ContextWatched")
+	}
+}
+
+func TestRetryTargetDelay(t *testing.T) {
+	fake := watchtest.NewFake(nil)
+	fake.SetError(errors.New("unavailable"))
+	clock := watchtest.NewClock(time.Now())
+	w := watch.New(watch.RetryTarget(fake, 3, time.Minute), watch.WithClock(clock))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Updated()
+		done <- err
+	}()
+
+	// each delay waits on the watch's clock
+	for attempt := 1; attempt < 3; attempt++ {
+		clock.BlockUntil(1)
+		if calls := fake.Calls(); calls != attempt {
+			t.Fatalf("%d attempts before delay %d, want %d", calls, attempt, attempt)
+		}
+		if attempt == 2 {
+			fake.SetContent([]byte("recovered"))
+		}
+		clock.Advance(time.Minute)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("Updated() error = %v once the target recovered", err)
+	}
+	if calls := fake.Calls(); calls != 3 {
+		t.Errorf("%d attempts, want 3", calls)
+	}
+}
+
+func TestRetryTargetStopped(t *testing.T) {
+	fake := watchtest.NewFake(nil)
+	fake.SetError(errors.New("unavailable"))
+	w := watch.New(watch.RetryTarget(fake, 3, time.Hour))
+	_, cancel := w.OnIntervalWithOpts(time.Hour, watch.OnIntervalOpts{EmitInitial: true})
+
+	for fake.Calls() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	w.Stop()
+
+	// the loop can only exit once its check has given up
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		cancel()
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop didn't cut short the delay between attempts")
+	}
+}
+
+func TestFailOpenWrappersForwardContext(t *testing.T) {
+	for name, target := range map[string]watch.Watched{
+		"ClassifyErrors":  watch.ClassifyErrors(blockingTarget{}, func(error) bool { return true }),
+		"DynamicFailOpen": watch.DynamicFailOpen(blockingTarget{}, func() bool { return true }),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if id, ok := target.(watch.Identifier); !ok || id.Identity() != "blocking" {
+				t.Error("identity of the wrapped target not forwarded")
+			}
+			if _, ok := target.(watch.ContextWatched); !ok {
+				t.Fatal("wrapping a ContextWatched isn't one")
+			}
+
+			w := watch.New(target, watch.WithCheckTimeout(10*time.Millisecond))
+			changed, err := w.Updated()
+			if !errors.Is(err, watch.ErrCheckTimeout) {
+				t.Errorf("Updated() error = %v, want ErrCheckTimeout", err)
+			}
+			if !changed {
+				t.Error("wrapper's fail-open policy not applied")
+			}
+		})
+	}
+}
+
+// cancelledTarget is a ContextWatched that fails if its ctx is already done.
+type cancelledTarget struct{}
+
+func (cancelledTarget) FailOpen() bool           { return false }
+func (cancelledTarget) Content() ([]byte, error) { return []byte("content"), nil }
+func (cancelledTarget) ContentCtx(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return []byte("content"), nil
+}
+
+func TestDirectChecksAfterStop(t *testing.T) {
+	w := watch.New(cancelledTarget{})
+	w.Stop()
+
+	if _, err := w.Updated(); err != nil {
+		t.Errorf("Updated() after Stop failed: %v", err)
+	}
+	if _, _, err := w.UpdatedSince(nil); err != nil {
+		t.Errorf("UpdatedSince() after Stop failed: %v", err)
+	}
+	if _, _, err := w.Explain(); err != nil {
+		t.Errorf("Explain() after Stop failed: %v", err)
+	}
+}
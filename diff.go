@@ -0,0 +1,96 @@
+package watch
+
+import (
+	"bytes"
+	"errors"
+)
+
+type divergence struct {
+	targets [2]Watched
+
+	// cmp holds the content handling options targets are compared with
+	cmp *watcher
+}
+
+var _ Watched = &divergence{}
+var _ ErrorClassifier = &divergence{}
+
+// contents of a divergence target, the first of which is Diff's baseline
+var (
+	inSync   = []byte("in sync")
+	diverged = []byte("diverged")
+)
+
+// Diff constructs a Watch of whether two targets' contents differ, e.g. a
+// primary config and its replica. Updated reports whether they currently
+// differ and OnInterval style loops emit when they fall out of or back into
+// sync. A failed check of either target is subject to that target's fail-open
+// policy.
+//
+// Normalizers, content filters, WithHash and WithEquals among opts decide
+// whether the two contents are the same; the other options configure the
+// returned Watch as usual.
+func Diff(a, b Watched, opts ...Option) Watch {
+	cmp := &watcher{config: config{hash: md5Hash}}
+	for _, opt := range opts {
+		opt(cmp)
+	}
+	d := &divergence{targets: [2]Watched{a, b}, cmp: cmp}
+
+	// the watch itself only sees whether the targets are in sync, which the
+	// content handling options don't apply to
+	return NewAgainst(d, inSync, append(opts, func(w *watcher) {
+		w.hash, w.normalizers, w.equals, w.identity = md5Hash, nil, nil, ""
+	})...)
+}
+
+// FailOpen reports true if either target would fail open.
+func (d *divergence) FailOpen() bool {
+	return d.targets[0].FailOpen() || d.targets[1].FailOpen()
+}
+
+// FailOpenOn defers to the policy of the target whose check failed.
+func (d *divergence) FailOpenOn(err error) bool {
+	var ce childError
+	if errors.As(err, &ce) {
+		return failOpen(d.targets[ce.index], ce.err)
+	}
+	return d.FailOpen()
+}
+
+func (d *divergence) Content() ([]byte, error) {
+	var contents [2][]byte
+	for i, t := range d.targets {
+		content, err := t.Content()
+		if err != nil {
+			return nil, childError{i, contentErr(t, err)}
+		}
+		contents[i] = content
+	}
+
+	same, err := d.same(contents)
+	if err != nil {
+		return nil, err
+	}
+	if same {
+		return inSync, nil
+	}
+	return diverged, nil
+}
+
+// same reports whether contents are the same by the options Diff was given.
+func (d *divergence) same(contents [2][]byte) (bool, error) {
+	if d.cmp.equals != nil {
+		return d.cmp.equals(contents[0], contents[1]), nil
+	}
+
+	var hashes [2][]byte
+	for i, content := range contents {
+		hash, err := d.cmp.digest(content)
+		if err != nil {
+			return false, childError{i, contentErr(d.targets[i], err)}
+		}
+		hashes[i] = hash
+	}
+	return bytes.Equal(hashes[0], hashes[1]), nil
+}
@@ -0,0 +1,37 @@
+package watch_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/falun/watch"
+	"github.com/falun/watch/watchtest"
+)
+
+func TestDiffOptions(t *testing.T) {
+	for name, opt := range map[string]watch.Option{
+		"normalizer": watch.WithNormalizer(watch.NormalizeJSON),
+		"equals": watch.WithEquals(func(old, new []byte) bool {
+			return bytes.Equal(bytes.ReplaceAll(old, []byte(" "), nil), bytes.ReplaceAll(new, []byte(" "), nil))
+		}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			a := watchtest.NewFake([]byte(`{"a": 1}`))
+			b := watchtest.NewFake([]byte(`{"a":1}`))
+			w := watch.Diff(a, b, opt)
+
+			differ, err := w.Updated()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if differ {
+				t.Error("contents the option deems the same reported as differing")
+			}
+
+			b.SetContent([]byte(`{"a":2}`))
+			if differ, err := w.Updated(); err != nil || !differ {
+				t.Errorf("Updated() = %v, %v for differing contents; want true, nil", differ, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type watchedDir struct {
+	root      string
+	recursive bool
+	failOpen  bool
+}
+
+var _ Watched = &watchedDir{}
+var _ Identifier = &watchedDir{}
+
+// DirectoryTarget constructs a Watched wrapper for the directory tree at root.
+// Its content is a listing of every entry's path, size, and modification time
+// so that additions, deletions, and modifications are all detected. When
+// recursive is false only the immediate children of root are listed.
+func DirectoryTarget(root string, recursive bool, failOpen bool) Watched {
+	return watchedDir{root, recursive, failOpen}
+}
+
+func (wd watchedDir) FailOpen() bool   { return wd.failOpen }
+func (wd watchedDir) Identity() string { return wd.root }
+func (wd watchedDir) Content() ([]byte, error) {
+	var entries []dirEntry
+
+	if wd.recursive {
+		err := filepath.Walk(wd.root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == wd.root {
+				return nil
+			}
+			rel, err := filepath.Rel(wd.root, path)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, dirEntry{filepath.ToSlash(rel), info})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to walk directory: %w", missing(err))
+		}
+	} else {
+		infos, err := ioutil.ReadDir(wd.root)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read directory: %w", missing(err))
+		}
+		for _, info := range infos {
+			entries = append(entries, dirEntry{info.Name(), info})
+		}
+	}
+
+	// the listing order of the underlying filesystem is not something we want
+	// to react to so always render entries sorted by path
+	sort.Slice(entries, func(i, j int) bool { return entries[i].path < entries[j].path })
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		if e.info.IsDir() {
+			fmt.Fprintf(&buf, "%s/\n", e.path)
+			continue
+		}
+		fmt.Fprintf(&buf, "%s\t%d\t%d\n", e.path, e.info.Size(), e.info.ModTime().UnixNano())
+	}
+	return buf.Bytes(), nil
+}
+
+type dirEntry struct {
+	path string
+	info os.FileInfo
+}
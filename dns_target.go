@@ -0,0 +1,63 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDNSTimeout bounds each lookup made by DNSTarget.
+const DefaultDNSTimeout = 5 * time.Second
+
+type watchedDNS struct {
+	host     string
+	failOpen bool
+}
+
+var _ ContextWatched = &watchedDNS{}
+var _ Identifier = &watchedDNS{}
+
+// DNSTarget constructs a Watched wrapper for the addresses host resolves to,
+// from both its A and AAAA records. Its content is the sorted set of
+// addresses so that only a change to the set, e.g. on failover, is detected
+// and not the order the resolver returns them in. A host that doesn't exist
+// is an error matching ErrTargetMissing.
+func DNSTarget(host string, failOpen bool) Watched {
+	return watchedDNS{host, failOpen}
+}
+
+func (wd watchedDNS) FailOpen() bool   { return wd.failOpen }
+func (wd watchedDNS) Identity() string { return wd.host }
+func (wd watchedDNS) Content() ([]byte, error) {
+	return wd.ContentCtx(context.Background())
+}
+
+func (wd watchedDNS) ContentCtx(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultDNSTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, wd.host)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, fmt.Errorf("Unable to resolve host: %w: %w", ErrTargetMissing, err)
+		}
+		return nil, fmt.Errorf("Unable to resolve host: %w", err)
+	}
+
+	ips := make([]string, 0, len(addrs))
+	seen := map[string]bool{}
+	for _, addr := range addrs {
+		ip := addr.IP.String()
+		if !seen[ip] {
+			seen[ip] = true
+			ips = append(ips, ip)
+		}
+	}
+	sort.Strings(ips)
+	return []byte(strings.Join(ips, "\n")), nil
+}
@@ -0,0 +1,31 @@
+package watch
+
+import (
+	"fmt"
+	"os"
+)
+
+type watchedEnvVar struct {
+	name     string
+	failOpen bool
+}
+
+var _ Watched = &watchedEnvVar{}
+var _ Identifier = &watchedEnvVar{}
+
+// EnvVarTarget constructs a Watched wrapper for the value of an environment
+// variable. An unset variable is treated as an error while a variable set to
+// the empty string has empty content.
+func EnvVarTarget(name string, failOpen bool) Watched {
+	return watchedEnvVar{name, failOpen}
+}
+
+func (we watchedEnvVar) FailOpen() bool   { return we.failOpen }
+func (we watchedEnvVar) Identity() string { return "$" + we.name }
+func (we watchedEnvVar) Content() ([]byte, error) {
+	value, ok := os.LookupEnv(we.name)
+	if !ok {
+		return nil, fmt.Errorf("Environment variable %v is not set: %w", we.name, ErrTargetMissing)
+	}
+	return []byte(value), nil
+}
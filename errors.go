@@ -0,0 +1,104 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrTargetMissing is matched (via errors.Is) by errors from targets that
+// don't currently exist, e.g. a file that hasn't been created yet. Errors
+// returned by the package's targets also wrap their underlying cause so that
+// other conditions, such as os.ErrPermission, can be tested for as well.
+var ErrTargetMissing = errors.New("target does not exist")
+
+// ErrContentTooLarge is matched by errors from targets whose content exceeds
+// their configured size limit.
+var ErrContentTooLarge = errors.New("content exceeds size limit")
+
+// ErrCheckTimeout is matched by errors from checks that exceeded the timeout
+// set by WithCheckTimeout.
+var ErrCheckTimeout = errors.New("check timed out")
+
+// missing marks err as matching ErrTargetMissing if it reports that a file
+// does not exist.
+func missing(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %w", ErrTargetMissing, err)
+	}
+	return err
+}
+
+// ErrorClassifier may be implemented by a Watched to decide whether to fail
+// open based on the error encountered, e.g. to fail open on a transient
+// network error but not on a missing resource. When implemented it is used in
+// place of FailOpen.
+type ErrorClassifier interface {
+	FailOpenOn(err error) bool
+}
+
+// contentErr annotates err, from fetching target's content, with what target
+// is if it has an identity (c.f. Identifier) so that errors from one of many
+// watches can be told apart.
+func contentErr(target Watched, err error) error {
+	if id := identity(target); id != "" {
+		return fmt.Errorf("Unable to get content of %s: %w", id, err)
+	}
+	return fmt.Errorf("Unable to get target content: %w", err)
+}
+
+// checkErr wraps an error comparing target's content with the target's
+// identity so it can be told apart from other targets' errors.
+func checkErr(target Watched, err error) error {
+	if id := identity(target); id != "" {
+		return fmt.Errorf("Unable to check %s: %w", id, err)
+	}
+	return fmt.Errorf("Unable to check target: %w", err)
+}
+
+// failOpen reports whether target should fail open for err.
+func failOpen(target Watched, err error) bool {
+	if ec, ok := target.(ErrorClassifier); ok {
+		return ec.FailOpenOn(err)
+	}
+	return target.FailOpen()
+}
+
+type classifiedTarget struct {
+	decorated
+	classify func(error) bool
+}
+
+var _ ErrorClassifier = &classifiedTarget{}
+
+// ClassifyErrors wraps target so that its fail-open behavior is decided per
+// error by classify rather than by target's FailOpen. Like the decorators
+// (c.f. FilterTarget) it keeps target's identity and cancellability.
+func ClassifyErrors(target Watched, classify func(err error) bool) Watched {
+	return decorate(classifiedTarget{decorated{target}, classify}, target)
+}
+
+func (ct classifiedTarget) FailOpenOn(err error) bool { return ct.classify(err) }
+
+type dynamicTarget struct {
+	decorated
+	failOpen func() bool
+}
+
+// DynamicFailOpen wraps target so that whether it fails open is decided by
+// calling failOpen each time a check fails, e.g. to be strict during startup
+// and fail open once a service is up:
+//
+//	var steady atomic.Bool
+//	target := DynamicFailOpen(FileTarget(path, false), steady.Load)
+//	...
+//	steady.Store(true)
+//
+// It takes precedence over both target's FailOpen and any ErrorClassifier it
+// implements, and keeps target's identity and cancellability.
+func DynamicFailOpen(target Watched, failOpen func() bool) Watched {
+	return decorate(dynamicTarget{decorated{target}, failOpen}, target)
+}
+
+func (dt dynamicTarget) FailOpen() bool            { return dt.failOpen() }
+func (dt dynamicTarget) FailOpenOn(err error) bool { return dt.failOpen() }
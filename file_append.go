@@ -0,0 +1,76 @@
+package watch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sync"
+)
+
+// appendTail is how many bytes before a file's previous end are compared to
+// confirm it was only appended to.
+const appendTail = 64
+
+// appendCache holds the running hash of an append only file as of the last
+// check.
+type appendCache struct {
+	mu   sync.Mutex
+	info os.FileInfo
+	size int64
+	hash hash.Hash
+	tail []byte
+}
+
+func (wf watchedFile) appendedContent() ([]byte, error) {
+	f, err := os.Open(wf.path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read config file: %w", missing(err))
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to stat config file: %w", err)
+	}
+	size := info.Size()
+
+	c := wf.appended
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.appendedTo(f, info) {
+		c.size, c.hash, c.tail = 0, sha256.New(), nil
+	}
+	// only read up to the size we stat'd; anything appended since is picked
+	// up by the next check
+	if _, err := io.Copy(c.hash, io.NewSectionReader(f, c.size, size-c.size)); err != nil {
+		c.info = nil
+		return nil, fmt.Errorf("Unable to read config file: %w", err)
+	}
+
+	tail := make([]byte, min(size, appendTail))
+	if _, err := f.ReadAt(tail, size-int64(len(tail))); err != nil {
+		c.info = nil
+		return nil, fmt.Errorf("Unable to read config file: %w", err)
+	}
+	c.info, c.size, c.tail = info, size, tail
+
+	return []byte(fmt.Sprintf("%d\x00%x", size, c.hash.Sum(nil))), nil
+}
+
+// appendedTo reports whether f is the file last checked and has at most been
+// appended to since.
+func (c *appendCache) appendedTo(f *os.File, info os.FileInfo) bool {
+	if c.info == nil || !os.SameFile(c.info, info) || info.Size() < c.size {
+		return false
+	}
+
+	tail := make([]byte, len(c.tail))
+	if _, err := f.ReadAt(tail, c.size-int64(len(tail))); err != nil {
+		return false
+	}
+	return bytes.Equal(tail, c.tail)
+}
@@ -0,0 +1,64 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FileEvents constructs a Watch for a file that is checked whenever the
+// operating system reports activity on it rather than on a timer; the interval
+// given to OnInterval and friends is ignored. An error is returned if
+// notifications are unavailable, in which case File remains a polling
+// fallback.
+//
+// The file's parent directory is what's actually observed so that a file
+// replaced by a rename, or one that doesn't exist yet, is still seen. Stop
+// releases the underlying notifier.
+func FileEvents(path string) (Watch, error) {
+	return FileEventsWithFallback(path, 0)
+}
+
+// FileEventsWithFallback behaves like FileEvents but also polls the file every
+// fallback to catch changes notifications can miss, e.g. on network
+// filesystems.
+func FileEventsWithFallback(path string, fallback time.Duration) (Watch, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create file notifier: %w", err)
+	}
+	if err := fsw.Add(filepath.Dir(path)); err != nil {
+		fsw.Close()
+		return nil, fmt.Errorf("Unable to watch directory: %w", err)
+	}
+
+	w := newWatcher(FileTarget(path, true))
+	w.events = newNotifier()
+	w.fallback = fallback
+
+	go func(name string) {
+		for {
+			select {
+			case ev, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) == name {
+					w.events.notify()
+				}
+
+			case _, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}(filepath.Clean(path))
+
+	context.AfterFunc(w.stopCtx, func() { fsw.Close() })
+
+	return w, nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package watch
+
+import "os"
+
+// fileMeta describes the mode of a file; ownership isn't available here.
+func fileMeta(info os.FileInfo) string {
+	return info.Mode().String()
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package watch
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileMeta describes the mode and ownership of a file.
+func fileMeta(info os.FileInfo) string {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fmt.Sprintf("%v %d:%d", info.Mode(), st.Uid, st.Gid)
+	}
+	return info.Mode().String()
+}
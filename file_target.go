@@ -1,29 +1,220 @@
 package watch
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
-	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
 )
 
+// FileStrategy selects how a FileTarget detects changes.
+type FileStrategy int
+
+const (
+	// ContentHash reads the whole file on every check.
+	ContentHash FileStrategy = iota
+
+	// MtimeOnly never reads the file; its content is the file's size and
+	// modification time. It is the cheapest strategy but misses edits that
+	// keep both, e.g. within the filesystem's timestamp granularity.
+	MtimeOnly
+
+	// MtimeThenContent stats the file first and only reads it when its size
+	// or modification time changed (c.f. FileOpts.UseStat), giving most of
+	// the speed of MtimeOnly while still comparing content.
+	MtimeThenContent
+)
+
+// FileOpts controls optional behavior of a FileTarget.
+type FileOpts struct {
+	// Strategy selects how changes are detected. The default is ContentHash.
+	Strategy FileStrategy
+
+	// UseStat skips reading the file when it is the same file, with the same
+	// size and modification time, as at the last read, reusing the previously
+	// read content. It is the same as using the MtimeThenContent strategy.
+	UseStat bool
+
+	// Decompress inflates gzip compressed files (detected by their magic
+	// bytes) so that the logical content is compared rather than compressed
+	// bytes that can differ for the same data. Other files are read as is.
+	Decompress bool
+
+	// MaxBytes, if positive, fails reads of files larger than this many bytes
+	// (after decompression, when enabled) rather than loading them.
+	MaxBytes int64
+
+	// IncludeLinkTarget resolves symlinks in the path and includes the
+	// resolved path in the content, so that repointing a link is detected even
+	// if the old and new destinations have the same content. A dangling link
+	// is an error.
+	IncludeLinkTarget bool
+
+	// IncludeMeta includes the file's mode and, where the platform has them,
+	// its owning uid and gid in the content, so that e.g. a chmod is detected
+	// even though the file's data didn't change.
+	IncludeMeta bool
+
+	// AppendOnly suits files that are only ever appended to, such as logs:
+	// when a file has grown since the last check, with its previous end
+	// unchanged, only the appended bytes are read and folded into a running
+	// hash, so a check costs the size of the change rather than of the file.
+	// Any other change is caught by hashing the file afresh, except for a
+	// rewrite of earlier data that leaves the file's previous end untouched,
+	// which isn't detected. The content is then the file's size and hash
+	// rather than its data, and Decompress and MaxBytes don't apply.
+	AppendOnly bool
+}
+
 type watchedFile struct {
 	path     string
 	failOpen bool
+	opts     FileOpts
+	stat     *statCache
+	appended *appendCache
 }
 
 var _ Watched = &watchedFile{}
+var _ Identifier = &watchedFile{}
+
+// statCache holds the most recently read content of a file alongside the
+// stat info it had when read.
+type statCache struct {
+	mu      sync.Mutex
+	info    os.FileInfo
+	content []byte
+}
 
 // FileTarget constructs a Watched wrapper for a file at a given path and allows
 // selection of whether failing to access the file should result in an update
-// signal.
-func FileTarget(path string, failOpen bool) Watched {
-	return watchedFile{path, failOpen}
+// signal. The file is opened by path on every check, so replacing it (e.g. by
+// renaming a new file over it) is seen like any other change.
+func FileTarget(path string, failOpen bool, opts ...FileOpts) Watched {
+	wf := watchedFile{path: path, failOpen: failOpen}
+	if len(opts) > 0 {
+		wf.opts = opts[0]
+	}
+	if wf.opts.UseStat || wf.opts.Strategy == MtimeThenContent {
+		wf.stat = &statCache{}
+	}
+	if wf.opts.AppendOnly {
+		wf.appended = &appendCache{}
+	}
+	return wf
 }
 
-func (wf watchedFile) FailOpen() bool { return wf.failOpen }
+func (wf watchedFile) FailOpen() bool   { return wf.failOpen }
+func (wf watchedFile) Identity() string { return wf.path }
 func (wf watchedFile) Content() ([]byte, error) {
-	configContents, err := ioutil.ReadFile(wf.path)
+	var prefix []byte
+	if wf.opts.IncludeLinkTarget {
+		resolved, err := filepath.EvalSymlinks(wf.path)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to resolve config file: %w", missing(err))
+		}
+		prefix = []byte(resolved + "\x00")
+	}
+	if wf.opts.IncludeMeta {
+		info, err := os.Stat(wf.path)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to stat config file: %w", missing(err))
+		}
+		prefix = append(prefix, fileMeta(info)+"\x00"...)
+	}
+
+	var content []byte
+	var err error
+	switch {
+	case wf.opts.Strategy == MtimeOnly:
+		content, err = wf.mtime()
+	case wf.appended != nil:
+		content, err = wf.appendedContent()
+	case wf.stat != nil:
+		content, err = wf.statContent()
+	default:
+		content, err = wf.read()
+	}
+	if err != nil || prefix == nil {
+		return content, err
+	}
+	return append(prefix, content...), nil
+}
+
+func (wf watchedFile) mtime() ([]byte, error) {
+	info, err := os.Stat(wf.path)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to read config file: %v", err)
+		return nil, fmt.Errorf("Unable to stat config file: %w", missing(err))
+	}
+	return []byte(fmt.Sprintf("%d\x00%d", info.Size(), info.ModTime().UnixNano())), nil
+}
+
+func (wf watchedFile) read() ([]byte, error) {
+	f, err := os.Open(wf.path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read config file: %w", missing(err))
+	}
+	defer f.Close()
+
+	if wf.opts.MaxBytes > 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to stat config file: %w", err)
+		}
+		if info.Size() > wf.opts.MaxBytes {
+			return nil, fmt.Errorf("Unable to read config file %v: %w", wf.path, tooLarge(wf.opts.MaxBytes))
+		}
+	}
+
+	configContents, err := readAtMost(f, wf.opts.MaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read config file %v: %w", wf.path, err)
+	}
+	if wf.opts.Decompress && bytes.HasPrefix(configContents, gzipMagic) {
+		content, err := gunzip(configContents, wf.opts.MaxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to decompress config file %v: %w", wf.path, err)
+		}
+		return content, nil
 	}
 	return configContents, nil
 }
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func gunzip(compressed []byte, max int64) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readAtMost(zr, max)
+}
+
+func (wf watchedFile) statContent() ([]byte, error) {
+	info, err := os.Stat(wf.path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to stat config file: %w", missing(err))
+	}
+
+	wf.stat.mu.Lock()
+	defer wf.stat.mu.Unlock()
+
+	// a file atomically replaced by a rename is a different file even if its
+	// size and modification time happen to match
+	if cached := wf.stat.info; cached != nil &&
+		os.SameFile(cached, info) &&
+		info.Size() == cached.Size() &&
+		info.ModTime().Equal(cached.ModTime()) {
+		return wf.stat.content, nil
+	}
+
+	content, err := wf.read()
+	if err != nil {
+		return nil, err
+	}
+	wf.stat.info = info
+	wf.stat.content = content
+	return content, nil
+}
@@ -0,0 +1,56 @@
+package watch_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/falun/watch"
+)
+
+func TestFileTargetRenamedOver(t *testing.T) {
+	for name, opts := range map[string]watch.FileOpts{
+		"default":  {},
+		"use stat": {UseStat: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config")
+			mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+			write := func(path, content string) {
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				// same size and modification time, so only the file's identity
+				// tells the two apart
+				if err := os.Chtimes(path, mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			write(path, "old")
+			w := watch.New(watch.FileTarget(path, false, opts))
+			if _, err := w.Updated(); err != nil {
+				t.Fatal(err)
+			}
+
+			replacement := filepath.Join(dir, "config.new")
+			write(replacement, "new")
+			if err := os.Rename(replacement, path); err != nil {
+				t.Fatal(err)
+			}
+
+			changed, err := w.Updated()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !changed {
+				t.Error("file renamed over the watched path not reported as changed")
+			}
+			if changed, _ := w.Updated(); changed {
+				t.Error("unchanged file reported as changed")
+			}
+		})
+	}
+}
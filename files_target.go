@@ -0,0 +1,37 @@
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+)
+
+type watchedFiles struct {
+	paths    []string
+	failOpen bool
+}
+
+var _ Watched = &watchedFiles{}
+var _ Identifier = &watchedFiles{}
+
+// FilesTarget constructs a Watched wrapper that treats several files as a single
+// unit. Each file's content is prefixed with its path and length so that a
+// change to the set of paths is detected as well as a change to any one file.
+func FilesTarget(paths []string, failOpen bool) Watched {
+	return watchedFiles{append([]string(nil), paths...), failOpen}
+}
+
+func (wf watchedFiles) FailOpen() bool   { return wf.failOpen }
+func (wf watchedFiles) Identity() string { return fmt.Sprint(wf.paths) }
+func (wf watchedFiles) Content() ([]byte, error) {
+	var buf bytes.Buffer
+	for _, path := range wf.paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read config file: %w", missing(err))
+		}
+		fmt.Fprintf(&buf, "%s\x00%d\n", path, len(content))
+		buf.Write(content)
+	}
+	return buf.Bytes(), nil
+}
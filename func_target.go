@@ -0,0 +1,68 @@
+package watch
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+type watchedFunc struct {
+	content  func() ([]byte, error)
+	failOpen bool
+}
+
+var _ Watched = &watchedFunc{}
+
+// FuncTarget constructs a Watched wrapper whose content is returned by fn. It
+// is the simplest way to watch something without defining a new type.
+func FuncTarget(fn func() ([]byte, error), failOpen bool) Watched {
+	return watchedFunc{fn, failOpen}
+}
+
+// StringTarget constructs a Watched wrapper whose content is the string
+// returned by fn.
+func StringTarget(fn func() (string, error), failOpen bool) Watched {
+	return FuncTarget(func() ([]byte, error) {
+		s, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	}, failOpen)
+}
+
+// ValueTarget constructs a Watched wrapper for in-memory state, e.g. held in an
+// atomic.Value that other go routines update, whose content is returned by
+// get. Since get can't fail the target never fails open. Nil content is
+// treated as empty.
+func ValueTarget(get func() []byte) Watched {
+	return FuncTarget(func() ([]byte, error) {
+		if content := get(); content != nil {
+			return content, nil
+		}
+		return []byte{}, nil
+	}, false)
+}
+
+// ReaderTarget constructs a Watched wrapper whose content is read from the
+// reader returned by fn. The reader is closed after reading if it is an
+// io.Closer.
+func ReaderTarget(fn func() (io.Reader, error), failOpen bool) Watched {
+	return FuncTarget(func() ([]byte, error) {
+		r, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := r.(io.Closer); ok {
+			defer c.Close()
+		}
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read content: %w", err)
+		}
+		return content, nil
+	}, failOpen)
+}
+
+func (wf watchedFunc) FailOpen() bool           { return wf.failOpen }
+func (wf watchedFunc) Content() ([]byte, error) { return wf.content() }
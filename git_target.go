@@ -0,0 +1,57 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+type watchedGitRef struct {
+	args     []string
+	failOpen bool
+}
+
+var _ Watched = &watchedGitRef{}
+var _ Identifier = &watchedGitRef{}
+
+// GitRefTarget constructs a Watched wrapper for the commit a ref (branch, tag,
+// etc.) resolves to in the local git repository at repoDir, so that a change
+// is reported whenever the ref moves.
+func GitRefTarget(repoDir, ref string, failOpen bool) Watched {
+	return watchedGitRef{
+		[]string{"-C", repoDir, "rev-parse", "--verify", "--quiet", ref + "^{commit}"},
+		failOpen,
+	}
+}
+
+// GitRemoteRefTarget constructs a Watched wrapper for the commit(s) a ref
+// points to in a remote repository, found with git ls-remote so that no clone
+// is needed.
+func GitRemoteRefTarget(remote, ref string, failOpen bool) Watched {
+	return watchedGitRef{[]string{"ls-remote", remote, ref}, failOpen}
+}
+
+func (wg watchedGitRef) FailOpen() bool { return wg.failOpen }
+func (wg watchedGitRef) Identity() string {
+	return exec.Command("git", wg.args...).String()
+}
+func (wg watchedGitRef) Content() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCommandTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "git", wg.args...).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) == 0 {
+			// rev-parse --quiet fails silently when the ref doesn't exist
+			return nil, fmt.Errorf("Unable to resolve ref with %v: %w", wg.Identity(), ErrTargetMissing)
+		}
+		return nil, fmt.Errorf("Unable to resolve ref with %v: %w", wg.Identity(), err)
+	}
+
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return nil, fmt.Errorf("Unable to resolve ref with %v: %w", wg.Identity(), ErrTargetMissing)
+	}
+	return output, nil
+}
@@ -0,0 +1,55 @@
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+type watchedGlob struct {
+	pattern  string
+	failOpen bool
+}
+
+var _ Watched = &watchedGlob{}
+var _ Identifier = &watchedGlob{}
+
+// GlobTarget constructs a Watched wrapper for the files matching a glob
+// pattern (c.f. filepath.Match). A file starting or stopping to match is
+// detected as well as a change to the content of any matching file.
+// Directories that match are watched for being present only.
+func GlobTarget(pattern string, failOpen bool) Watched {
+	return watchedGlob{pattern, failOpen}
+}
+
+func (wg watchedGlob) FailOpen() bool   { return wg.failOpen }
+func (wg watchedGlob) Identity() string { return wg.pattern }
+func (wg watchedGlob) Content() ([]byte, error) {
+	// matches are sorted so the listing order isn't seen as a change
+	paths, err := filepath.Glob(wg.pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to expand glob: %w", err)
+	}
+
+	var buf bytes.Buffer
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to stat config file: %w", missing(err))
+		}
+		if info.IsDir() {
+			fmt.Fprintf(&buf, "%s/\n", path)
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read config file: %w", missing(err))
+		}
+		fmt.Fprintf(&buf, "%s\x00%d\n", path, len(content))
+		buf.Write(content)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,48 @@
+package watch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Group manages the lifecycle of many watch loops so they can be stopped
+// together. The zero value is ready to use.
+type Group struct {
+	mu      sync.Mutex
+	cancels []context.CancelFunc
+	closed  bool
+}
+
+// Add starts a loop for w as with OnInterval and returns its channel. The loop
+// runs until the group is closed. Adding to a closed group returns a closed
+// channel without starting a loop.
+func (g *Group) Add(w Watch, interval time.Duration) <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		ch := make(chan struct{})
+		close(ch)
+		return ch
+	}
+
+	ch, cancel := w.OnInterval(interval)
+	g.cancels = append(g.cancels, cancel)
+	return ch
+}
+
+// Close stops every loop in the group and waits for them to exit, so all of
+// the channels returned by Add are closed by the time it returns. It is safe
+// to call more than once.
+func (g *Group) Close() {
+	g.mu.Lock()
+	cancels := g.cancels
+	g.cancels = nil
+	g.closed = true
+	g.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
@@ -0,0 +1,76 @@
+// Package grpchealth provides a watch target for the status reported by a
+// gRPC server's standard health service (grpc.health.v1.Health).
+package grpchealth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/falun/watch"
+)
+
+// DefaultTimeout bounds each health check made by a target.
+const DefaultTimeout = 10 * time.Second
+
+type watchedHealth struct {
+	client   healthpb.HealthClient
+	target   string
+	service  string
+	failOpen bool
+}
+
+var _ watch.ContextWatched = &watchedHealth{}
+var _ watch.Identifier = &watchedHealth{}
+
+// HealthTarget constructs a watch.Watched wrapper for the serving status of
+// service, as reported by the health service on conn. Its content is the
+// status's name, e.g. SERVING or NOT_SERVING, so that a transition between
+// statuses is seen as a change. An empty service asks after the server as a
+// whole. A service unknown to the server is reported with an error matching
+// watch.ErrTargetMissing.
+func HealthTarget(conn grpc.ClientConnInterface, service string, failOpen bool) watch.Watched {
+	var target string
+	if cc, ok := conn.(*grpc.ClientConn); ok {
+		target = cc.Target()
+	}
+	return watchedHealth{healthpb.NewHealthClient(conn), target, service, failOpen}
+}
+
+// Dial creates a client connection to target (c.f. grpc.NewClient) and
+// returns a HealthTarget for service using it. The caller is responsible for
+// closing the connection once it is done watching.
+func Dial(target, service string, failOpen bool, opts ...grpc.DialOption) (watch.Watched, *grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to create gRPC client: %w", err)
+	}
+	return HealthTarget(conn, service, failOpen), conn, nil
+}
+
+func (wh watchedHealth) FailOpen() bool { return wh.failOpen }
+func (wh watchedHealth) Identity() string {
+	return fmt.Sprintf("%s/%s", wh.target, wh.service)
+}
+func (wh watchedHealth) Content() ([]byte, error) {
+	return wh.ContentCtx(context.Background())
+}
+
+func (wh watchedHealth) ContentCtx(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	resp, err := wh.client.Check(ctx, &healthpb.HealthCheckRequest{Service: wh.service})
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("Unable to check health: %w: %w", watch.ErrTargetMissing, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to check health: %w", err)
+	}
+	return []byte(resp.GetStatus().String()), nil
+}
@@ -0,0 +1,114 @@
+package watch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Handle controls a watch loop started by Start.
+type Handle struct {
+	// C receives a value for each detected change. It is closed once the loop
+	// stops.
+	C <-chan struct{}
+
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	paused  bool
+	resumed chan struct{}
+	check   chan struct{}
+	reset   chan struct{}
+	err     error
+
+	// exited is closed once the loop stops
+	exited chan struct{}
+}
+
+func (w *watcher) Start(interval time.Duration, opts OnIntervalOpts) *Handle {
+	h := &Handle{
+		resumed: make(chan struct{}, 1),
+		check:   make(chan struct{}, 1),
+		reset:   make(chan struct{}, 1),
+		exited:  make(chan struct{}),
+	}
+	opts.handle = h
+	h.C, h.cancel = w.onInterval(context.Background(), interval, opts)
+
+	return h
+}
+
+// Cancel stops the loop, waiting for it to exit.
+func (h *Handle) Cancel() { h.cancel() }
+
+// Pause stops the loop from checking the target until Resume is called.
+func (h *Handle) Pause() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.paused = true
+}
+
+// Resume restarts checks of a paused loop. The target is checked right away
+// against the content seen before pausing, so any changes made while paused
+// are reported as a single change.
+func (h *Handle) Resume() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.paused {
+		return
+	}
+	h.paused = false
+	select {
+	case h.resumed <- struct{}{}:
+	default:
+	}
+}
+
+// CheckNow asks the loop to check the target right away rather than waiting
+// for the next tick, emitting if it changed. The check shares the loop's state
+// so a change is reported once however it is found. Requests made while a
+// check is already pending are merged into it, and requests made while paused
+// are ignored.
+func (h *Handle) CheckNow() {
+	select {
+	case h.check <- struct{}{}:
+	default:
+	}
+}
+
+// Reset makes the loop forget the content it last saw and check the target
+// right away, so its current content is emitted as a change. A paused loop
+// emits once it is resumed.
+func (h *Handle) Reset() {
+	select {
+	case h.reset <- struct{}{}:
+	default:
+	}
+}
+
+// Err returns the error that stopped the loop if it gave up after reaching
+// OnIntervalOpts.MaxConsecutiveErrors, and nil otherwise.
+func (h *Handle) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func (h *Handle) setErr(err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.err = err
+}
+
+func (h *Handle) isPaused() bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.paused
+}
@@ -0,0 +1,32 @@
+package watch
+
+import "time"
+
+// NewHybrid constructs a watch for a target that is checked whenever a value
+// is received from events and, as a safety net for missed events, every
+// fallback as well. The interval given to OnInterval and friends is ignored.
+// A zero fallback disables polling altogether. Since changes are still found
+// by comparing content, an event and a poll that see the same change only
+// report it once.
+func NewHybrid(target Watched, events <-chan struct{}, fallback time.Duration, opts ...Option) Watch {
+	w := newWatcher(target, opts...)
+	w.events = newNotifier()
+	w.fallback = fallback
+
+	go func() {
+		for {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					return
+				}
+				w.events.notify()
+
+			case <-w.stopCtx.Done():
+				return
+			}
+		}
+	}()
+
+	return w
+}
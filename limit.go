@@ -0,0 +1,28 @@
+package watch
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// readAtMost reads r to EOF, failing with ErrContentTooLarge if it holds more
+// than max bytes. A max of zero or less reads without limit.
+func readAtMost(r io.Reader, max int64) ([]byte, error) {
+	if max <= 0 {
+		return ioutil.ReadAll(r)
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > max {
+		return nil, tooLarge(max)
+	}
+	return content, nil
+}
+
+func tooLarge(max int64) error {
+	return fmt.Errorf("%w: limit is %d bytes", ErrContentTooLarge, max)
+}
@@ -0,0 +1,26 @@
+package watch
+
+import "context"
+
+// Map constructs a Watch that only reports a change when a projection of w's
+// target's content changes, e.g. a single field of a JSON document. project
+// is applied before hashing, after any normalizers w has, and the content
+// reported (e.g. by OnIntervalContent) is still the target's. A failed
+// projection is subject to the target's fail-open policy like any other
+// failed check.
+//
+// The returned Watch has the same configuration as w, if w was made by this
+// package, but its own state, so it doesn't affect and isn't affected by
+// calls to w's Updated. It is stopped along with w.
+func Map(w Watch, project func([]byte) ([]byte, error)) Watch {
+	parent, ok := w.(*watcher)
+	if !ok {
+		return New(w.Target(), WithNormalizer(project))
+	}
+
+	m := &watcher{config: parent.config}
+	m.normalizers = append(append([]func([]byte) ([]byte, error)(nil), parent.normalizers...), project)
+	m.stopCtx, m.stop = context.WithCancel(parent.stopCtx)
+	m.primeState()
+	return m
+}
@@ -0,0 +1,38 @@
+package watch_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/falun/watch"
+	"github.com/falun/watch/watchtest"
+)
+
+func TestMapKeepsConfig(t *testing.T) {
+	fake := watchtest.NewFake([]byte("a:1"))
+	w := watch.New(fake, watch.WithMinLength(3))
+	m := watch.Map(w, func(content []byte) ([]byte, error) {
+		key, _, _ := bytes.Cut(content, []byte(":"))
+		return key, nil
+	})
+
+	if changed, err := m.Updated(); err != nil || !changed {
+		t.Fatalf("first Updated() = %v, %v; want true, nil", changed, err)
+	}
+
+	fake.SetContent([]byte("a:2"))
+	if changed, _ := m.Updated(); changed {
+		t.Error("change outside the projection reported")
+	}
+
+	// shorter than w's minimum length so ignored by m too
+	fake.SetContent([]byte("b"))
+	if changed, _ := m.Updated(); changed {
+		t.Error("content shorter than the parent's minimum length reported")
+	}
+
+	fake.SetContent([]byte("b:1"))
+	if changed, _ := m.Updated(); !changed {
+		t.Error("change to the projection not reported")
+	}
+}
@@ -0,0 +1,114 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type multiTarget struct {
+	watches []Watch
+}
+
+var _ Watched = &multiTarget{}
+var _ ErrorClassifier = &multiTarget{}
+
+// Multi constructs a Watch that reports an update whenever any of the provided
+// watches' targets change. All checks are driven by the returned Watch; the
+// children are not polled independently and their own Updated state is left
+// untouched.
+//
+// The returned Watch's content, e.g. from OnIntervalContent or Snapshot, lists
+// each child's digest by index, one per line as "<index>:<hex digest>", so the
+// children that changed can be found by comparing two of them with
+// MultiChanged.
+func Multi(watches ...Watch) Watch {
+	return New(&multiTarget{watches})
+}
+
+// FailOpen reports true if any child would fail open.
+func (mt *multiTarget) FailOpen() bool {
+	for _, w := range mt.watches {
+		if w.Target().FailOpen() {
+			return true
+		}
+	}
+	return false
+}
+
+// FailOpenOn defers to the policy of the child whose check failed.
+func (mt *multiTarget) FailOpenOn(err error) bool {
+	var ce childError
+	if errors.As(err, &ce) {
+		return failOpen(mt.watches[ce.index].Target(), ce.err)
+	}
+	return mt.FailOpen()
+}
+
+func (mt *multiTarget) Content() ([]byte, error) {
+	var buf bytes.Buffer
+	for i, w := range mt.watches {
+		hash, err := digestOf(w)
+		if err != nil {
+			return nil, childError{i, err}
+		}
+		fmt.Fprintf(&buf, "%d:%x\n", i, hash)
+	}
+	return buf.Bytes(), nil
+}
+
+// MultiChanged returns, in order, the indexes of the watches whose digest
+// differs between before and after, two contents of a Watch made by Multi
+// (c.f. OnIntervalContent). before may be nil, e.g. for the first change, in
+// which case every watch is reported.
+func MultiChanged(before, after []byte) []int {
+	old := multiDigests(before)
+	var changed []int
+	for i, digest := range multiDigests(after) {
+		if i >= len(old) || old[i] != digest {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
+
+// multiDigests splits a multiTarget's content into its children's digests.
+func multiDigests(content []byte) []string {
+	var digests []string
+	for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+		if _, digest, ok := strings.Cut(line, ":"); ok {
+			digests = append(digests, digest)
+		}
+	}
+	return digests
+}
+
+// digestOf returns a hash of w's current content. Watches from this package
+// are checked with their own configuration (hash, normalizers, etc.); others
+// have their target's content hashed as is.
+func digestOf(w Watch) ([]byte, error) {
+	if cw, ok := w.(*watcher); ok {
+		hash, _, err := cw.check(context.Background())
+		return hash, err
+	}
+
+	content, err := w.Target().Content()
+	if err != nil {
+		return nil, contentErr(w.Target(), err)
+	}
+	return md5Hash(content), nil
+}
+
+// childError records which of an aggregate's watches failed to be checked.
+type childError struct {
+	index int
+	err   error
+}
+
+func (ce childError) Error() string {
+	return fmt.Sprintf("Unable to check watch %d: %v", ce.index, ce.err)
+}
+
+func (ce childError) Unwrap() error { return ce.err }
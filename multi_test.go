@@ -0,0 +1,36 @@
+package watch_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/falun/watch"
+	"github.com/falun/watch/watchtest"
+)
+
+func TestMultiChanged(t *testing.T) {
+	a, b := watchtest.NewFake([]byte("a")), watchtest.NewFake([]byte("b"))
+	w := watch.Multi(watch.New(a), watch.New(b))
+
+	snapshot := func() []byte {
+		if _, err := w.Updated(); err != nil {
+			t.Fatal(err)
+		}
+		content, _, _, _ := w.Snapshot()
+		return content
+	}
+
+	first := snapshot()
+	if got := watch.MultiChanged(nil, first); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("MultiChanged(nil, first) = %v, want [0 1]", got)
+	}
+
+	b.SetContent([]byte("b2"))
+	second := snapshot()
+	if got := watch.MultiChanged(first, second); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("MultiChanged(first, second) = %v, want [1]", got)
+	}
+	if got := watch.MultiChanged(second, second); got != nil {
+		t.Errorf("MultiChanged(second, second) = %v, want none", got)
+	}
+}
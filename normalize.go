@@ -0,0 +1,58 @@
+package watch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// NormalizeJSON is a normalizer (c.f. WithNormalizer) that re-encodes a JSON
+// document with sorted object keys and no insignificant whitespace so that
+// semantically identical documents hash the same.
+func NormalizeJSON(content []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	// keep numbers verbatim rather than round tripping them through float64
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("Unable to decode JSON: %w", err)
+	}
+	// More would miss a stray closing bracket
+	if err := dec.Decode(new(interface{})); err != io.EOF {
+		return nil, fmt.Errorf("Unexpected data after JSON document")
+	}
+
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to encode JSON: %w", err)
+	}
+	return canonical, nil
+}
+
+// NormalizeWhitespace is a normalizer (c.f. WithNormalizer) that ignores
+// cosmetic whitespace: line endings are converted to \n, trailing whitespace is
+// stripped from every line, and trailing blank lines, including the final
+// newline, are dropped. Leading whitespace is kept since it is significant in
+// formats like YAML.
+func NormalizeWhitespace(content []byte) ([]byte, error) {
+	lines := bytes.Split(bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n")), []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimRight(line, " \t\r")
+	}
+	return bytes.TrimRight(bytes.Join(lines, []byte("\n")), "\n"), nil
+}
+
+// NormalizePresence returns a normalizer (c.f. WithNormalizer) that reduces
+// content to whether or not it contains marker, so that a watch only reports
+// the marker appearing or disappearing and ignores every other change.
+func NormalizePresence(marker []byte) func([]byte) ([]byte, error) {
+	present, absent := []byte{1}, []byte{0}
+	return func(content []byte) ([]byte, error) {
+		if bytes.Contains(content, marker) {
+			return present, nil
+		}
+		return absent, nil
+	}
+}
@@ -0,0 +1,48 @@
+package watch_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/falun/watch"
+)
+
+func TestNormalizeJSON(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+		fails    bool
+	}{
+		{in: `{"b": 2, "a": 1}`, want: `{"a":1,"b":2}`},
+		{in: " [1, 2]\n", want: `[1,2]`},
+		{in: `{"a":1}}`, fails: true},
+		{in: `[1]]`, fails: true},
+		{in: `{"a":1} {"b":2}`, fails: true},
+		{in: `{"a":`, fails: true},
+	} {
+		got, err := watch.NormalizeJSON([]byte(tc.in))
+		if tc.fails {
+			if err == nil {
+				t.Errorf("NormalizeJSON(%q) = %q, want an error", tc.in, got)
+			}
+			continue
+		}
+		if err != nil || string(got) != tc.want {
+			t.Errorf("NormalizeJSON(%q) = %q, %v; want %q, nil", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestNormalizeErrorIdentifiesTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"a":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := watch.New(watch.FileTarget(path, false), watch.WithNormalizer(watch.NormalizeJSON))
+	_, err := w.Updated()
+	if err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("Updated() = %v, want an error naming %s", err, path)
+	}
+}
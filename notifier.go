@@ -0,0 +1,65 @@
+package watch
+
+import "sync"
+
+// notifier fans a stream of events out to any number of subscribers. Events
+// are coalesced per subscriber so a slow subscriber never blocks notify.
+type notifier struct {
+	mu   sync.Mutex
+	subs map[chan struct{}]struct{}
+}
+
+func newNotifier() *notifier {
+	return &notifier{subs: map[chan struct{}]struct{}{}}
+}
+
+// subscribe returns a channel that receives a value after each call to notify
+// and a func that releases the subscription, closing the channel after
+// dropping any pending value.
+func (n *notifier) subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	n.mu.Lock()
+	n.subs[ch] = struct{}{}
+	n.mu.Unlock()
+
+	return ch, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if _, ok := n.subs[ch]; ok {
+			n.release(ch)
+		}
+	}
+}
+
+// closeAll releases every subscription.
+func (n *notifier) closeAll() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.subs {
+		n.release(ch)
+	}
+}
+
+// release must be called with mu held.
+func (n *notifier) release(ch chan struct{}) {
+	delete(n.subs, ch)
+	select {
+	case <-ch:
+	default:
+	}
+	close(ch)
+}
+
+func (n *notifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package watch
+
+import "time"
+
+// Logger is the minimal logging interface used by WithLogger; *log.Logger
+// satisfies it.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// Observer receives notifications about the checks a Watch performs, e.g. to
+// feed metrics. Methods are called synchronously from whichever go routine is
+// performing the check so implementations must be safe for concurrent use and
+// should return quickly.
+type Observer interface {
+	// OnCheck is called before each check of the target.
+	OnCheck()
+
+	// OnChange is called when a check finds the target has changed.
+	OnChange()
+
+	// OnError is called when a check fails.
+	OnError(err error)
+}
+
+// LatencyObserver may be implemented by an Observer to also be told how long
+// each check spent fetching the target's content, including any retries (c.f.
+// WithRetry) but not hashing or signalling the change.
+type LatencyObserver interface {
+	Observer
+
+	// OnCheckDone is called after each check with the time spent fetching and
+	// whether the check counted as a change, as a failed check of a target
+	// that fails open does.
+	OnCheckDone(fetched time.Duration, changed bool)
+}
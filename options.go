@@ -0,0 +1,242 @@
+package watch
+
+import (
+	"crypto/md5"
+	"math/rand"
+	"time"
+)
+
+// Option configures optional behavior of a Watch constructed by New.
+type Option func(*watcher)
+
+// WithHash replaces the function used to fingerprint target content. The
+// default is md5; any function that maps equal content to equal output (e.g.
+// sha256, fnv, xxhash) is suitable.
+func WithHash(hash func([]byte) []byte) Option {
+	return func(w *watcher) { w.hash = hash }
+}
+
+// WithNormalizer canonicalizes content before it is hashed so that content
+// differing only in insignificant ways isn't reported as a change. A
+// normalizer error is treated like a failure to fetch content. Normalizers are
+// applied in the order given.
+func WithNormalizer(normalize func([]byte) ([]byte, error)) Option {
+	return func(w *watcher) { w.normalizers = append(w.normalizers, normalize) }
+}
+
+// WithContentFilter transforms content before it is hashed, e.g. to strip a
+// volatile timestamp or select only the lines that matter, so that changes to
+// the rest of the content are ignored. Filters and normalizers are applied in
+// the order given.
+func WithContentFilter(filter func([]byte) []byte) Option {
+	return WithNormalizer(func(content []byte) ([]byte, error) {
+		return filter(content), nil
+	})
+}
+
+// WithEquals decides whether the target has changed with equals, e.g. to
+// compare JSON documents semantically, rather than by comparing hashes. Change
+// is judged against the most recent content that equals reported as
+// different; the watch keeps a copy of that content to do so, so memory use
+// grows with the size of the target. A watch made by NewAgainst compares
+// content against its baseline with equals.
+func WithEquals(equals func(old, new []byte) bool) Option {
+	return func(w *watcher) { w.equals = equals }
+}
+
+// WithTargetIdentity folds the target's identity (c.f. Identifier) into its
+// hash, so that watches of different targets with the same content, e.g. two
+// empty files, never produce the same hash and can't be mistaken for one
+// another by anything comparing them, like an aggregate keyed by hash. Targets
+// that don't implement Identifier are hashed as usual.
+func WithTargetIdentity() Option {
+	return func(w *watcher) { w.identity = identity(w.target) }
+}
+
+// WithClock sets the source of time for the watch's loops, e.g. to a fake in
+// tests so that checks can be driven without sleeping. The default is the
+// system clock. Check timeouts (c.f. WithCheckTimeout) always use the system
+// clock.
+func WithClock(c Clock) Option {
+	return func(w *watcher) { w.clock = c }
+}
+
+// WithObserver registers an Observer to be notified of every check made by
+// Updated and the watch's interval loops.
+func WithObserver(o Observer) Option {
+	return func(w *watcher) { w.observers = append(w.observers, o) }
+}
+
+// WithMinLength ignores content shorter than n bytes, treating it as no
+// change. This smooths over writers that truncate a file before rewriting it,
+// which would otherwise be reported as two changes if a check lands mid-write.
+func WithMinLength(n int) Option {
+	return func(w *watcher) { w.minLength = n }
+}
+
+// WithCheckTimeout bounds how long each check waits for the target's content.
+// A check that takes longer fails with an error matching ErrCheckTimeout,
+// which is subject to the target's fail-open policy like any other error.
+func WithCheckTimeout(timeout time.Duration) Option {
+	return func(w *watcher) { w.checkTimeout = timeout }
+}
+
+// WithRetry tries fetching the target's content up to attempts times in all,
+// waiting delay between tries, before treating a check as failed. This rides
+// out transient errors such as a file being missing while it is atomically
+// replaced. Retries count against the check timeout (c.f. WithCheckTimeout):
+// once it elapses the check fails with an error matching ErrCheckTimeout that
+// wraps the last error seen.
+func WithRetry(attempts int, delay time.Duration) Option {
+	return func(w *watcher) {
+		w.retryAttempts = attempts
+		w.retryDelay = delay
+	}
+}
+
+// WithLogger logs the outcome of every check, whether a change, no change, or
+// an error, to l. It is meant as a debugging aid when a watch isn't firing as
+// expected.
+func WithLogger(l Logger) Option {
+	return func(w *watcher) { w.logger = l }
+}
+
+// WithPrimeState, when prime is true, records the target's content when the
+// watch is constructed so that the first call to Updated only reports a
+// change if the content has changed since. If the content can't be fetched
+// the error is returned by the first call to Updated, after which the watch
+// behaves as if it had not been primed.
+func WithPrimeState(prime bool) Option {
+	return func(w *watcher) { w.prime = prime }
+}
+
+func md5Hash(content []byte) []byte {
+	sum := md5.Sum(content)
+	// slicify for go
+	return sum[:]
+}
+
+// OnIntervalOpts controls optional behavior of an OnInterval watch loop.
+type OnIntervalOpts struct {
+	// EmitInitial checks the target as soon as the loop starts and emits for
+	// its current content instead of waiting for the first interval to elapse.
+	EmitInitial bool
+
+	// BackoffMultiplier, if greater than 1, stretches the delay before the next
+	// check by this factor after each consecutive failed check. The delay
+	// returns to the loop's interval after a successful check.
+	BackoffMultiplier float64
+
+	// BackoffMax caps the delay reached by backing off. Zero leaves it
+	// uncapped.
+	BackoffMax time.Duration
+
+	// Jitter randomly spreads each delay between checks by up to this fraction
+	// of it in either direction, e.g. 0.1 for ±10%, so that many watches
+	// started together don't poll in lockstep. Values above MaxJitter are
+	// capped at it so that a delay is never cut to nothing.
+	Jitter float64
+
+	// Align schedules checks on wall clock boundaries that are a multiple of
+	// the delay between them, e.g. on the minute for a minute interval,
+	// rather than relative to when the loop started, so that loops in many
+	// processes check together. Combined with Jitter each check is delayed by
+	// up to that fraction of the interval past its boundary but never made
+	// early.
+	Align bool
+
+	// Active, if set, gates checks by time, e.g. to only react to changes
+	// during business hours: checks that would be made while it reports false
+	// are skipped. The first check once it reports true again compares
+	// against the content last emitted, so any changes made in the meantime
+	// are reported as a single change.
+	Active func(now time.Time) bool
+
+	// Coalesce buffers a single pending signal so the loop never waits on a
+	// slow receiver before its next check. Changes detected while a signal is
+	// pending are folded into it.
+	Coalesce bool
+
+	// Dedup suppresses changes to content seen in any of this many of the
+	// most recent checks, so a target flapping between states (A, B, A, B, ...)
+	// is only reported when it reaches a state it hasn't been in lately. Zero
+	// reports every change.
+	Dedup int
+
+	// MaxConsecutiveErrors, if positive, stops the loop and closes its channel
+	// once this many checks in a row have failed, e.g. because the target was
+	// deleted. The final error is available from Handle.Err for loops begun
+	// with Start.
+	MaxConsecutiveErrors int
+
+	// Cooldown, if positive, pauses the loop for this long after each change
+	// it emits, e.g. to let the reload it triggered settle. The target's state
+	// once the cooldown is over becomes the new baseline, so changes made
+	// during it are not reported.
+	Cooldown time.Duration
+
+	// prime checks the target as soon as the loop starts to establish a
+	// baseline without emitting for it.
+	prime bool
+
+	// forgetOnFailOpen drops the loop's baseline when a check fails open so
+	// that the next successful check is reported as a change.
+	forgetOnFailOpen bool
+
+	// handled, if set, is called after each emit; if it reports false the
+	// change is emitted again, with the then current content, on the next
+	// check
+	handled func() bool
+
+	// handle, if set, controls the loop
+	handle *Handle
+}
+
+// wait returns how long to wait for the next check given the current delay
+// between checks.
+func (o OnIntervalOpts) wait(now time.Time, delay time.Duration) time.Duration {
+	if !o.Align {
+		return o.jitter(delay)
+	}
+
+	wait := now.Truncate(delay).Add(delay).Sub(now)
+	if o.Jitter > 0 {
+		// only ever late so that a check never lands before its boundary
+		wait += time.Duration(rand.Float64() * o.jitterFraction() * float64(delay))
+	}
+	return wait
+}
+
+// MaxJitter is the most OnIntervalOpts.Jitter is taken to be.
+const MaxJitter = 0.9
+
+// jitterFraction returns o.Jitter capped at MaxJitter.
+func (o OnIntervalOpts) jitterFraction() float64 {
+	return min(o.Jitter, MaxJitter)
+}
+
+// jitter returns delay randomly adjusted by up to o.Jitter of itself.
+func (o OnIntervalOpts) jitter(delay time.Duration) time.Duration {
+	if o.Jitter <= 0 {
+		return delay
+	}
+	spread := (rand.Float64()*2 - 1) * o.jitterFraction()
+	return delay + time.Duration(float64(delay)*spread)
+}
+
+// backoff returns the delay to use after a failed check that followed a check
+// scheduled delay after its predecessor.
+func (o OnIntervalOpts) backoff(delay time.Duration) time.Duration {
+	if o.BackoffMultiplier <= 1 {
+		return delay
+	}
+	next := time.Duration(float64(delay) * o.BackoffMultiplier)
+	if next < delay {
+		// overflowed; stay where we are
+		next = delay
+	}
+	if o.BackoffMax > 0 && next > o.BackoffMax {
+		next = o.BackoffMax
+	}
+	return next
+}
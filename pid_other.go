@@ -0,0 +1,15 @@
+//go:build !unix && !windows
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// processRunning fails: finding a process here succeeds whether or not it
+// exists.
+func processRunning(pid int) (bool, error) {
+	return false, fmt.Errorf("Unable to check for process on %s: %w", runtime.GOOS, errors.ErrUnsupported)
+}
@@ -0,0 +1,50 @@
+package watch
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+type watchedPID struct {
+	path     string
+	failOpen bool
+}
+
+var _ Watched = &watchedPID{}
+var _ Identifier = &watchedPID{}
+
+// PIDFileTarget constructs a Watched wrapper for the liveness of the process
+// whose PID is recorded in the file at path. Its content is the PID and
+// whether that process is running, so the process starting or stopping is
+// detected, as is the file being rewritten for a new process. A missing or
+// unparseable PID file is an error, as is every check on platforms other than
+// Unix and Windows, where a process can't be looked up without signalling it.
+func PIDFileTarget(path string, failOpen bool) Watched {
+	return watchedPID{path, failOpen}
+}
+
+func (wp watchedPID) FailOpen() bool   { return wp.failOpen }
+func (wp watchedPID) Identity() string { return wp.path }
+func (wp watchedPID) Content() ([]byte, error) {
+	raw, err := ioutil.ReadFile(wp.path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read PID file: %w", missing(err))
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil || pid <= 0 {
+		return nil, fmt.Errorf("Unable to parse PID file %v: %q is not a PID", wp.path, strings.TrimSpace(string(raw)))
+	}
+
+	running, err := processRunning(pid)
+	if err != nil {
+		return nil, err
+	}
+	state := "stopped"
+	if running {
+		state = "running"
+	}
+	return []byte(fmt.Sprintf("%d %s", pid, state)), nil
+}
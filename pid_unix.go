@@ -0,0 +1,16 @@
+//go:build unix
+
+package watch
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning reports whether a process with the given PID exists.
+func processRunning(pid int) (bool, error) {
+	// signal 0 only checks the process could be signalled; a permission error
+	// means it exists but belongs to someone else
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM), nil
+}
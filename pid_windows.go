@@ -0,0 +1,16 @@
+//go:build windows
+
+package watch
+
+import "os"
+
+// processRunning reports whether a process with the given PID exists.
+func processRunning(pid int) (bool, error) {
+	// on Windows finding a process fails if it doesn't exist
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false, nil
+	}
+	p.Release()
+	return true, nil
+}
@@ -0,0 +1,60 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+type watchedRange struct {
+	path     string
+	offset   int64
+	length   int64
+	failOpen bool
+}
+
+var _ Watched = &watchedRange{}
+var _ Identifier = &watchedRange{}
+
+// RangeFileTarget constructs a Watched wrapper for length bytes of the file at
+// path starting at offset, e.g. a header holding a version field. Only that
+// window is read so changes elsewhere in the file, such as a growing tail, are
+// neither loaded nor detected. A file that ends within the window contributes
+// what it has. A negative offset or length fails every check.
+func RangeFileTarget(path string, offset, length int64, failOpen bool) Watched {
+	return watchedRange{path, offset, length, failOpen}
+}
+
+func (wr watchedRange) FailOpen() bool { return wr.failOpen }
+func (wr watchedRange) Identity() string {
+	return fmt.Sprintf("%s[%d:%d]", wr.path, wr.offset, wr.offset+wr.length)
+}
+func (wr watchedRange) Content() ([]byte, error) {
+	if wr.offset < 0 || wr.length < 0 {
+		return nil, fmt.Errorf("Unable to read config file %v: invalid range of %d bytes at offset %d", wr.path, wr.length, wr.offset)
+	}
+
+	f, err := os.Open(wr.path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read config file: %w", missing(err))
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to stat config file: %w", err)
+	}
+	// don't allocate more than the file could hold
+	length := wr.length
+	if rest := info.Size() - wr.offset; rest < length {
+		length = max(rest, 0)
+	}
+
+	buf := make([]byte, length)
+	n, err := f.ReadAt(buf, wr.offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("Unable to read config file: %w", err)
+	}
+	return buf[:n], nil
+}
@@ -0,0 +1,46 @@
+package watch_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/falun/watch"
+)
+
+func TestRangeFileTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name           string
+		offset, length int64
+		want           string
+		fails          bool
+	}{
+		{name: "within", offset: 2, length: 3, want: "234"},
+		{name: "past end", offset: 8, length: 5, want: "89"},
+		{name: "beyond end", offset: 20, length: 5, want: ""},
+		{name: "huge length", offset: 0, length: 1 << 62, want: "0123456789"},
+		{name: "negative length", offset: 0, length: -1, fails: true},
+		{name: "negative offset", offset: -1, length: 2, fails: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			content, err := watch.RangeFileTarget(path, tc.offset, tc.length, false).Content()
+			if tc.fails {
+				if err == nil {
+					t.Errorf("Content() = %q, want an error", content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tc.want {
+				t.Errorf("Content() = %q, want %q", content, tc.want)
+			}
+		})
+	}
+}
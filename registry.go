@@ -0,0 +1,88 @@
+package watch
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registered is a watch known to a Registry.
+type Registered struct {
+	Name  string
+	Watch Watch
+
+	// Handle controls the watch's loop, if it was registered with one.
+	Handle *Handle
+}
+
+// Registry tracks watches by name so that they can be inspected, e.g. by a
+// debug endpoint. The zero value is ready to use.
+type Registry struct {
+	mu      sync.Mutex
+	entries map[string]*Registered
+}
+
+// Register adds w under name, replacing any watch already registered with that
+// name. h may be nil for a watch that isn't run by Start. The returned func
+// removes the watch again, unless it has since been replaced.
+func (r *Registry) Register(name string, w Watch, h *Handle) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.entries == nil {
+		r.entries = map[string]*Registered{}
+	}
+	// entries are told apart by pointer as Watch implementations needn't be
+	// comparable
+	e := &Registered{Name: name, Watch: w, Handle: h}
+	r.entries[name] = e
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.entries[name] == e {
+			delete(r.entries, name)
+		}
+	}
+}
+
+// Start starts a loop for w as with Watch.Start and registers it under name.
+// The watch is unregistered once the loop is cancelled, by the Handle or by
+// stopping the watch, while a loop that gave up after
+// OnIntervalOpts.MaxConsecutiveErrors stays registered so that its error can
+// be inspected.
+func (r *Registry) Start(name string, w Watch, interval time.Duration, opts OnIntervalOpts) *Handle {
+	h := w.Start(interval, opts)
+	unregister := r.Register(name, w, h)
+	go func() {
+		<-h.exited
+		if h.Err() == nil {
+			unregister()
+		}
+	}()
+	return h
+}
+
+// Lookup returns the watch registered under name.
+func (r *Registry) Lookup(name string) (Registered, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[name]
+	if !ok {
+		return Registered{}, false
+	}
+	return *e, true
+}
+
+// Watches returns every registered watch, ordered by name.
+func (r *Registry) Watches() []Registered {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	watches := make([]Registered, 0, len(r.entries))
+	for _, e := range r.entries {
+		watches = append(watches, *e)
+	}
+	sort.Slice(watches, func(i, j int) bool { return watches[i].Name < watches[j].Name })
+	return watches
+}
@@ -0,0 +1,69 @@
+package watch_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/falun/watch"
+	"github.com/falun/watch/watchtest"
+)
+
+// uncomparable is a Watch implementation that can't be compared with ==.
+type uncomparable struct {
+	watch.Watch
+	tags []string
+}
+
+func TestRegistryUncomparableWatch(t *testing.T) {
+	var r watch.Registry
+	w := uncomparable{Watch: watch.New(watchtest.NewFake([]byte("content")))}
+
+	unregister := r.Register("w", w, nil)
+	replacement := r.Register("w", w, nil)
+	unregister()
+	if _, ok := r.Lookup("w"); !ok {
+		t.Error("unregistering a replaced registration removed its replacement")
+	}
+	replacement()
+	if _, ok := r.Lookup("w"); ok {
+		t.Error("watch still registered after unregistering")
+	}
+}
+
+func TestRegistryStartUnregistersOnCancel(t *testing.T) {
+	var r watch.Registry
+	w := watch.New(watchtest.NewFake([]byte("content")))
+
+	h := r.Start("w", w, time.Hour, watch.OnIntervalOpts{})
+	if e, ok := r.Lookup("w"); !ok || e.Handle != h {
+		t.Fatalf("Lookup() = %+v, %v; want the started loop", e, ok)
+	}
+
+	h.Cancel()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if _, ok := r.Lookup("w"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cancelled loop still registered")
+		}
+	}
+}
+
+func TestRegistryStartKeepsLoopsThatGaveUp(t *testing.T) {
+	var r watch.Registry
+	fake := watchtest.NewFake(nil)
+	fake.SetError(errors.New("unavailable"))
+	w := watch.New(fake)
+
+	h := r.Start("w", w, time.Millisecond, watch.OnIntervalOpts{MaxConsecutiveErrors: 1})
+	for range h.C {
+	}
+	if h.Err() == nil {
+		t.Fatal("loop stopped without giving up")
+	}
+	if _, ok := r.Lookup("w"); !ok {
+		t.Error("loop that gave up was unregistered")
+	}
+}
@@ -0,0 +1,41 @@
+package watch
+
+import "bytes"
+
+// hashRing remembers the most recent hashes added to it. A nil ring remembers
+// nothing.
+type hashRing struct {
+	hashes [][]byte
+	next   int
+}
+
+func newHashRing(size int) *hashRing {
+	if size <= 0 {
+		return nil
+	}
+	return &hashRing{hashes: make([][]byte, 0, size)}
+}
+
+func (r *hashRing) contains(hash []byte) bool {
+	if r == nil {
+		return false
+	}
+	for _, h := range r.hashes {
+		if bytes.Equal(h, hash) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *hashRing) add(hash []byte) {
+	if r == nil {
+		return
+	}
+	if len(r.hashes) < cap(r.hashes) {
+		r.hashes = append(r.hashes, hash)
+		return
+	}
+	r.hashes[r.next] = hash
+	r.next = (r.next + 1) % len(r.hashes)
+}
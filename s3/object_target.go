@@ -0,0 +1,157 @@
+// Package s3 provides watch targets for objects in Amazon S3.
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/falun/watch"
+)
+
+// DefaultTimeout bounds each request made by a target when no timeout is
+// provided.
+const DefaultTimeout = 30 * time.Second
+
+// Client is the subset of *s3.Client used by the targets in this package.
+type Client interface {
+	GetObject(ctx context.Context, params *awss3.GetObjectInput, optFns ...func(*awss3.Options)) (*awss3.GetObjectOutput, error)
+	HeadObject(ctx context.Context, params *awss3.HeadObjectInput, optFns ...func(*awss3.Options)) (*awss3.HeadObjectOutput, error)
+}
+
+var _ Client = &awss3.Client{}
+
+// ObjectOpts controls optional behavior of the targets in this package.
+type ObjectOpts struct {
+	// Timeout bounds each check, including downloading the body. Zero means
+	// DefaultTimeout.
+	Timeout time.Duration
+}
+
+type watchedObject struct {
+	client   Client
+	bucket   string
+	key      string
+	failOpen bool
+	timeout  time.Duration
+}
+
+var _ watch.ContextWatched = &watchedObject{}
+var _ watch.Identifier = &watchedObject{}
+
+func newWatchedObject(client Client, bucket, key string, failOpen bool, opts []ObjectOpts) watchedObject {
+	var o ObjectOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Timeout == 0 {
+		o.Timeout = DefaultTimeout
+	}
+	return watchedObject{client, bucket, key, failOpen, o.Timeout}
+}
+
+// ObjectTarget constructs a watch.Watched wrapper for the body of an S3
+// object, downloading it on every check. A missing object is reported with an
+// error matching watch.ErrTargetMissing.
+func ObjectTarget(client Client, bucket, key string, failOpen bool, opts ...ObjectOpts) watch.Watched {
+	return newWatchedObject(client, bucket, key, failOpen, opts)
+}
+
+func (wo watchedObject) FailOpen() bool   { return wo.failOpen }
+func (wo watchedObject) Identity() string { return "s3://" + wo.bucket + "/" + wo.key }
+func (wo watchedObject) Content() ([]byte, error) {
+	return wo.ContentCtx(context.Background())
+}
+
+func (wo watchedObject) ContentCtx(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, wo.timeout)
+	defer cancel()
+
+	body, _, err := wo.get(ctx)
+	return body, err
+}
+
+// get downloads the object, returning its body and ETag.
+func (wo watchedObject) get(ctx context.Context) ([]byte, string, error) {
+	out, err := wo.client.GetObject(ctx, &awss3.GetObjectInput{
+		Bucket: aws.String(wo.bucket),
+		Key:    aws.String(wo.key),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("Unable to get object: %w", missing(err))
+	}
+	defer out.Body.Close()
+
+	body, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("Unable to read object: %w", err)
+	}
+	return body, aws.ToString(out.ETag), nil
+}
+
+type watchedCachedObject struct {
+	watchedObject
+
+	mu   sync.Mutex
+	etag string
+	body []byte
+}
+
+var _ watch.ContextWatched = &watchedCachedObject{}
+
+// CachedObjectTarget constructs a watch.Watched wrapper for the body of an S3
+// object that is only downloaded when its ETag, fetched with a HeadObject
+// request on every check, differs from that of the last download.
+func CachedObjectTarget(client Client, bucket, key string, failOpen bool, opts ...ObjectOpts) watch.Watched {
+	return &watchedCachedObject{watchedObject: newWatchedObject(client, bucket, key, failOpen, opts)}
+}
+
+func (wo *watchedCachedObject) Content() ([]byte, error) {
+	return wo.ContentCtx(context.Background())
+}
+
+func (wo *watchedCachedObject) ContentCtx(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, wo.timeout)
+	defer cancel()
+
+	head, err := wo.client.HeadObject(ctx, &awss3.HeadObjectInput{
+		Bucket: aws.String(wo.bucket),
+		Key:    aws.String(wo.key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to head object: %w", missing(err))
+	}
+
+	wo.mu.Lock()
+	defer wo.mu.Unlock()
+
+	if etag := aws.ToString(head.ETag); etag != "" && etag == wo.etag {
+		return wo.body, nil
+	}
+
+	body, etag, err := wo.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	wo.etag = etag
+	wo.body = body
+	return body, nil
+}
+
+// missing marks err as matching watch.ErrTargetMissing if S3 reports that the
+// object does not exist.
+func missing(err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %w", watch.ErrTargetMissing, err)
+	}
+	return err
+}
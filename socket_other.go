@@ -0,0 +1,15 @@
+//go:build !unix
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// openPipe fails: a pipe can't be opened here without blocking on a writer.
+func openPipe(path string) (*os.File, error) {
+	return nil, fmt.Errorf("named pipes aren't supported on %s: %w", runtime.GOOS, errors.ErrUnsupported)
+}
@@ -0,0 +1,146 @@
+package watch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// DefaultSocketTimeout bounds each connection made by SocketTarget when no
+// timeout is provided.
+const DefaultSocketTimeout = 10 * time.Second
+
+// SocketOpts controls optional behavior of a SocketTarget.
+type SocketOpts struct {
+	// Timeout bounds connecting and reading. Zero means DefaultSocketTimeout.
+	Timeout time.Duration
+
+	// Delimiter, if set, stops reading once it has been read, for services
+	// that don't close the connection after sending their state. The content
+	// includes the delimiter.
+	Delimiter []byte
+
+	// MaxBytes, if positive, fails reads of more than this many bytes rather
+	// than loading them.
+	MaxBytes int64
+}
+
+type watchedSocket struct {
+	path     string
+	failOpen bool
+	opts     SocketOpts
+}
+
+var _ ContextWatched = &watchedSocket{}
+var _ Identifier = &watchedSocket{}
+
+// SocketTarget constructs a Watched wrapper for what a local service writes to
+// the Unix domain socket or named pipe at path. Each check connects to the
+// socket, or opens the pipe, and reads until EOF or SocketOpts.Delimiter. A
+// pipe with no writer reads as empty. Named pipes are only supported on Unix.
+func SocketTarget(path string, failOpen bool, opts ...SocketOpts) Watched {
+	ws := watchedSocket{path: path, failOpen: failOpen}
+	if len(opts) > 0 {
+		ws.opts = opts[0]
+	}
+	if ws.opts.Timeout == 0 {
+		ws.opts.Timeout = DefaultSocketTimeout
+	}
+	return ws
+}
+
+func (ws watchedSocket) FailOpen() bool   { return ws.failOpen }
+func (ws watchedSocket) Identity() string { return ws.path }
+func (ws watchedSocket) Content() ([]byte, error) {
+	return ws.ContentCtx(context.Background())
+}
+
+// deadlineReader is what a socket or pipe is read through.
+type deadlineReader interface {
+	io.ReadCloser
+	SetReadDeadline(t time.Time) error
+}
+
+func (ws watchedSocket) ContentCtx(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, ws.opts.Timeout)
+	defer cancel()
+
+	conn, err := ws.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+	// unblock the read if we are cancelled before the deadline
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	var r io.Reader = conn
+	if len(ws.opts.Delimiter) > 0 {
+		r = &delimitedReader{r: bufio.NewReader(conn), delim: ws.opts.Delimiter}
+	}
+	content, err := readAtMost(r, ws.opts.MaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read socket %v: %w", ws.path, err)
+	}
+	return content, nil
+}
+
+func (ws watchedSocket) open(ctx context.Context) (deadlineReader, error) {
+	info, err := os.Stat(ws.path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to stat socket: %w", missing(err))
+	}
+
+	if info.Mode()&os.ModeNamedPipe != 0 {
+		f, err := openPipe(ws.path)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to open pipe: %w", missing(err))
+		}
+		return f, nil
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", ws.path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to connect to socket: %w", err)
+	}
+	return conn, nil
+}
+
+// delimitedReader reads from r until delim has been read.
+type delimitedReader struct {
+	r     *bufio.Reader
+	delim []byte
+	tail  []byte
+	done  bool
+}
+
+func (dr *delimitedReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && !dr.done {
+		b, err := dr.r.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		p[n] = b
+		n++
+
+		dr.tail = append(dr.tail, b)
+		if len(dr.tail) > len(dr.delim) {
+			dr.tail = dr.tail[1:]
+		}
+		dr.done = bytes.Equal(dr.tail, dr.delim)
+	}
+	if n == 0 && dr.done {
+		return 0, io.EOF
+	}
+	return n, nil
+}
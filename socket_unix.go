@@ -0,0 +1,15 @@
+//go:build unix
+
+package watch
+
+import (
+	"os"
+	"syscall"
+)
+
+// openPipe opens the named pipe at path for reading.
+func openPipe(path string) (*os.File, error) {
+	// opened non-blocking so that opening doesn't wait for a writer and reads
+	// honor deadlines
+	return os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+}
@@ -0,0 +1,105 @@
+// Package sqlwatch provides watch targets backed by SQL queries.
+package sqlwatch
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/falun/watch"
+)
+
+// DefaultTimeout bounds each run of a query when no timeout is provided.
+const DefaultTimeout = 30 * time.Second
+
+// QueryOpts controls optional behavior of a QueryTarget.
+type QueryOpts struct {
+	// Args are the query's placeholder arguments.
+	Args []interface{}
+
+	// Timeout bounds each run of the query, including reading its rows. Zero
+	// means DefaultTimeout.
+	Timeout time.Duration
+}
+
+type watchedQuery struct {
+	db       *sql.DB
+	query    string
+	failOpen bool
+	opts     QueryOpts
+}
+
+var _ watch.ContextWatched = &watchedQuery{}
+var _ watch.Identifier = &watchedQuery{}
+
+// QueryTarget constructs a watch.Watched wrapper for the result of a query.
+// The rows are serialized in the order returned, so the query should include
+// an ORDER BY to avoid reporting a change when the database merely returns
+// rows in a different order.
+func QueryTarget(db *sql.DB, query string, failOpen bool, opts ...QueryOpts) watch.Watched {
+	wq := watchedQuery{db: db, query: query, failOpen: failOpen}
+	if len(opts) > 0 {
+		wq.opts = opts[0]
+	}
+	if wq.opts.Timeout == 0 {
+		wq.opts.Timeout = DefaultTimeout
+	}
+	return wq
+}
+
+func (wq watchedQuery) FailOpen() bool   { return wq.failOpen }
+func (wq watchedQuery) Identity() string { return wq.query }
+func (wq watchedQuery) Content() ([]byte, error) {
+	return wq.ContentCtx(context.Background())
+}
+
+func (wq watchedQuery) ContentCtx(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, wq.opts.Timeout)
+	defer cancel()
+
+	rows, err := wq.db.QueryContext(ctx, wq.query, wq.opts.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to run query: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read columns: %w", err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%q\n", cols)
+
+	values := make([]sql.RawBytes, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("Unable to read row: %w", err)
+		}
+		for i, v := range values {
+			if i > 0 {
+				buf.WriteByte('\t')
+			}
+			if v == nil {
+				buf.WriteString("NULL")
+			} else {
+				// quote values so that NULL, tabs, and newlines in the data
+				// can't be confused with the framing
+				fmt.Fprintf(&buf, "%q", []byte(v))
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to read rows: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
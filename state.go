@@ -0,0 +1,98 @@
+package watch
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// stateVersion identifies the format written by SaveState. It is the first
+// byte of the saved state and is followed by the hash's length, as a uvarint,
+// and the hash itself.
+const stateVersion = 1
+
+// SaveState writes w's current state (c.f. Watch.CurrentHash) to dst so that
+// it can be restored with LoadState, e.g. by the next run of a process, rather
+// than unchanged content being reported as updated after a restart.
+func SaveState(w Watch, dst io.Writer) error {
+	hash := w.CurrentHash()
+
+	buf := []byte{stateVersion}
+	buf = binary.AppendUvarint(buf, uint64(len(hash)))
+	buf = append(buf, hash...)
+	if _, err := dst.Write(buf); err != nil {
+		return fmt.Errorf("Unable to save watch state: %w", err)
+	}
+	return nil
+}
+
+// LoadState restores the state saved by SaveState into w (c.f. Watch.SetHash).
+func LoadState(w Watch, src io.Reader) error {
+	r := bufio.NewReader(src)
+
+	version, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("Unable to load watch state: %w", err)
+	}
+	if version != stateVersion {
+		return fmt.Errorf("Unable to load watch state: unsupported version %d", version)
+	}
+
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("Unable to load watch state: %w", err)
+	}
+	// hashes are small; anything else is corrupt rather than worth reading
+	if n > 1024 {
+		return fmt.Errorf("Unable to load watch state: hash length %d is too long", n)
+	}
+	hash := make([]byte, n)
+	if _, err := io.ReadFull(r, hash); err != nil {
+		return fmt.Errorf("Unable to load watch state: %w", err)
+	}
+
+	w.SetHash(hash)
+	return nil
+}
+
+// SaveStateFile saves w's state, as with SaveState, to the file at path. The
+// file is replaced atomically so a crash never leaves it partially written.
+func SaveStateFile(w Watch, path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("Unable to save watch state: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := SaveState(w, f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("Unable to save watch state: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("Unable to save watch state: %w", err)
+	}
+	return nil
+}
+
+// LoadStateFile restores the state saved by SaveStateFile into w. It is not an
+// error for the file not to exist, as on a process's first run; w is left
+// untouched.
+func LoadStateFile(w Watch, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to load watch state: %w", err)
+	}
+	defer f.Close()
+
+	return LoadState(w, f)
+}
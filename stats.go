@@ -0,0 +1,57 @@
+package watch
+
+import "time"
+
+// WatchStats describes the checks a Watch has made (c.f. Watch.Stats).
+type WatchStats struct {
+	// Checks counts every check of the target, successful or not.
+	Checks uint64
+
+	// Changes counts the checks that found the target changed, including
+	// failed checks of a target that fails open.
+	Changes uint64
+
+	// Errors counts the checks that failed.
+	Errors uint64
+
+	// ConsecutiveErrors counts the checks that have failed since the last
+	// successful one.
+	ConsecutiveErrors int
+
+	// LastCheck and LastChange are when the most recent check and the most
+	// recent check that found a change were made; they are zero if there
+	// hasn't been one.
+	LastCheck  time.Time
+	LastChange time.Time
+
+	// LastError is the error from the most recent failed check, if any.
+	LastError error
+}
+
+func (w *watcher) Stats() WatchStats {
+	w.statsMu.Lock()
+	defer w.statsMu.Unlock()
+	return w.stats
+}
+
+// count records the outcome of a check in the watch's stats.
+func (w *watcher) count(changed bool, err error) {
+	now := w.clock.Now()
+
+	w.statsMu.Lock()
+	defer w.statsMu.Unlock()
+
+	w.stats.Checks++
+	w.stats.LastCheck = now
+	if changed {
+		w.stats.Changes++
+		w.stats.LastChange = now
+	}
+	if err != nil {
+		w.stats.Errors++
+		w.stats.ConsecutiveErrors++
+		w.stats.LastError = err
+	} else {
+		w.stats.ConsecutiveErrors = 0
+	}
+}
@@ -0,0 +1,75 @@
+package watch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sharedLoop is a watch loop whose changes are fanned out to Subscribe's
+// subscribers.
+type sharedLoop struct {
+	subscribers *notifier
+	count       int
+	cancel      context.CancelFunc
+}
+
+func (w *watcher) Subscribe(interval time.Duration) (<-chan struct{}, context.CancelFunc) {
+	w.sharedMu.Lock()
+	defer w.sharedMu.Unlock()
+
+	loop, ok := w.shared[interval]
+	if !ok {
+		loop = w.startShared(interval)
+	}
+	loop.count++
+	ch, unsubscribe := loop.subscribers.subscribe()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			unsubscribe()
+
+			w.sharedMu.Lock()
+			loop.count--
+			last := loop.count == 0 && w.shared[interval] == loop
+			if last {
+				delete(w.shared, interval)
+			}
+			w.sharedMu.Unlock()
+
+			if last {
+				loop.cancel()
+			}
+		})
+	}
+}
+
+// startShared starts the loop shared by subscriptions at interval. It must be
+// called with sharedMu held.
+func (w *watcher) startShared(interval time.Duration) *sharedLoop {
+	if w.shared == nil {
+		w.shared = map[time.Duration]*sharedLoop{}
+	}
+
+	loop := &sharedLoop{subscribers: newNotifier()}
+	cancelFn, exited := w.spawn(context.Background(), func(done <-chan struct{}) {
+		w.poll(interval, OnIntervalOpts{}, done, func(change) bool {
+			loop.subscribers.notify()
+			return true
+		}, nil)
+
+		// stopped, either by the last subscriber or by Stop; in the latter
+		// case the remaining subscribers' channels must be closed
+		w.sharedMu.Lock()
+		defer w.sharedMu.Unlock()
+		if w.shared[interval] == loop {
+			delete(w.shared, interval)
+		}
+		loop.subscribers.closeAll()
+	})
+	loop.cancel = joined(cancelFn, exited)
+	w.shared[interval] = loop
+
+	return loop
+}
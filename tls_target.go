@@ -0,0 +1,65 @@
+package watch
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+// DefaultTLSTimeout bounds the dial and handshake made by TLSCertTarget when
+// no timeout is provided.
+const DefaultTLSTimeout = 10 * time.Second
+
+// TLSOpts controls optional behavior of a TLSCertTarget.
+type TLSOpts struct {
+	// Timeout bounds the dial and handshake. Zero means DefaultTLSTimeout.
+	Timeout time.Duration
+
+	// Config is used for the handshake, e.g. to trust a private CA. When nil
+	// the system roots are used and the server name is taken from the address.
+	Config *tls.Config
+}
+
+type watchedTLSCert struct {
+	addr     string
+	failOpen bool
+	dialer   *tls.Dialer
+}
+
+var _ Watched = &watchedTLSCert{}
+var _ Identifier = &watchedTLSCert{}
+
+// TLSCertTarget constructs a Watched wrapper for the leaf certificate served
+// by the TLS endpoint at addr (host:port). Its content is the certificate's
+// DER encoding so a renewed certificate is seen as a change.
+func TLSCertTarget(addr string, failOpen bool, opts ...TLSOpts) Watched {
+	var o TLSOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Timeout == 0 {
+		o.Timeout = DefaultTLSTimeout
+	}
+
+	return watchedTLSCert{addr, failOpen, &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: o.Timeout},
+		Config:    o.Config,
+	}}
+}
+
+func (wt watchedTLSCert) FailOpen() bool   { return wt.failOpen }
+func (wt watchedTLSCert) Identity() string { return wt.addr }
+func (wt watchedTLSCert) Content() ([]byte, error) {
+	conn, err := wt.dialer.Dial("tcp", wt.addr)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to complete TLS handshake: %w", err)
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("No certificate presented by %v", wt.addr)
+	}
+	return certs[0].Raw, nil
+}
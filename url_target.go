@@ -0,0 +1,120 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultURLTimeout is the request timeout used by URLTarget when none is
+// provided.
+const DefaultURLTimeout = 10 * time.Second
+
+// sharedClient is used by URLTargets that aren't given a client.
+var sharedClient = &http.Client{}
+
+// URLOpts controls optional behavior of a URLTarget.
+type URLOpts struct {
+	// Timeout bounds each GET request, including reading the body. Zero means
+	// DefaultURLTimeout.
+	Timeout time.Duration
+
+	// Client is used to make requests, e.g. to configure proxies or TLS. If
+	// nil a client shared by every URLTarget is used so that connections to
+	// the same host are reused.
+	Client *http.Client
+
+	// MaxBytes, if positive, fails fetches whose body is larger than this
+	// many bytes rather than loading it.
+	MaxBytes int64
+}
+
+type watchedURL struct {
+	url      string
+	failOpen bool
+	client   *http.Client
+	timeout  time.Duration
+	maxBytes int64
+
+	mu   sync.Mutex
+	etag string
+	body []byte
+}
+
+var _ ContextWatched = &watchedURL{}
+var _ Identifier = &watchedURL{}
+
+// URLTarget constructs a Watched wrapper that fetches url with a GET request.
+// Non-2xx responses are treated as errors. If the server provides an ETag it is
+// sent back as If-None-Match on later requests and a 304 response reuses the
+// previously fetched body rather than downloading it again.
+func URLTarget(url string, failOpen bool, opts ...URLOpts) Watched {
+	var o URLOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Timeout == 0 {
+		o.Timeout = DefaultURLTimeout
+	}
+	if o.Client == nil {
+		o.Client = sharedClient
+	}
+
+	return &watchedURL{
+		url:      url,
+		failOpen: failOpen,
+		client:   o.Client,
+		timeout:  o.Timeout,
+		maxBytes: o.MaxBytes,
+	}
+}
+
+func (wu *watchedURL) FailOpen() bool   { return wu.failOpen }
+func (wu *watchedURL) Identity() string { return wu.url }
+func (wu *watchedURL) Content() ([]byte, error) {
+	return wu.ContentCtx(context.Background())
+}
+
+func (wu *watchedURL) ContentCtx(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, wu.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wu.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to build request: %w", err)
+	}
+
+	wu.mu.Lock()
+	defer wu.mu.Unlock()
+
+	if wu.etag != "" {
+		req.Header.Set("If-None-Match", wu.etag)
+	}
+
+	resp, err := wu.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch url: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && wu.etag != "" {
+		return wu.body, nil
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("Unexpected response status from %v: %v: %w", wu.url, resp.Status, ErrTargetMissing)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Unexpected response status from %v: %v", wu.url, resp.Status)
+	}
+
+	body, err := readAtMost(resp.Body, wu.maxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read response body of %v: %w", wu.url, err)
+	}
+
+	wu.etag = resp.Header.Get("ETag")
+	wu.body = body
+	return body, nil
+}
@@ -0,0 +1,22 @@
+package watch_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/falun/watch"
+)
+
+func TestURLTargetStatusErrorIdentifiesURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	_, err := watch.URLTarget(srv.URL, false).Content()
+	if err == nil || !strings.Contains(err.Error(), srv.URL) {
+		t.Errorf("Content() = %v, want an error naming %s", err, srv.URL)
+	}
+}
@@ -1,9 +1,12 @@
 package watch
 
 import (
+	"bytes"
 	"context"
-	"crypto/md5"
+	"errors"
 	"fmt"
+	"iter"
+	"sync"
 	"time"
 )
 
@@ -12,24 +15,201 @@ import (
 // bytes (c.f. Watched.Content).
 type Watch interface {
 	// Updated returns whether or not the target has changed since last called.
-	// It always returns true on the first call and is not safe for concurrent
-	// use.
+	// It always returns true on the first call unless the watch was primed
+	// (c.f. WithPrimeState). It is safe for concurrent use;
+	// each change is reported as updated to exactly one caller.
 	//
 	// It does not interact or conflict with OnInterval signals.
 	Updated() (bool, error)
 
+	// Explain checks the target and reports whether Updated would find it
+	// changed along with a human readable reason, e.g. to debug a watch's
+	// normalizers. Unlike Updated it doesn't affect the watch's state, other
+	// than that of targets whose content depends on being checked (c.f. All).
+	Explain() (changed bool, reason string, err error)
+
+	// UpdatedSince returns whether the target has changed since token was
+	// returned by a previous call, along with a token representing its current
+	// state. It does not affect, and is not affected by, Updated, so callers
+	// can store the token elsewhere and poll without a long lived Watch. A nil
+	// token is always reported as changed. On error the given token is
+	// returned.
+	UpdatedSince(token []byte) (changed bool, newToken []byte, err error)
+
 	// OnInterval starts a go routine that will emit to a channel when the
 	// watched object changes. This will emit at most once per change and checks
 	// for updates as specified by the provided interval duration.
+	//
+	// The returned cancel func stops the go routine and waits for it to exit,
+	// so the channel is closed by the time it returns. A change that is still
+	// waiting for a receiver when the loop is cancelled is dropped rather than
+	// delivered; cancelling never blocks on it. The same holds for the cancel
+	// funcs returned by the other channel based variants below.
 	OnInterval(interval time.Duration) (<-chan struct{}, context.CancelFunc)
+
+	// OnIntervalCtx behaves like OnInterval but runs until ctx is done rather
+	// than returning its own cancel func.
+	OnIntervalCtx(ctx context.Context, interval time.Duration) <-chan struct{}
+
+	// OnIntervalWithOpts behaves like OnInterval with additional control over
+	// the watch loop (c.f. OnIntervalOpts).
+	OnIntervalWithOpts(interval time.Duration, opts OnIntervalOpts) (<-chan struct{}, context.CancelFunc)
+
+	// OnIntervalContent behaves like OnInterval but emits the content that
+	// triggered each change, saving the consumer from re-reading the target.
+	OnIntervalContent(interval time.Duration) (<-chan []byte, context.CancelFunc)
+
+	// OnIntervalEvents behaves like OnInterval but emits a ChangeEvent
+	// describing each change.
+	OnIntervalEvents(interval time.Duration) (<-chan ChangeEvent, context.CancelFunc)
+
+	// OnIntervalGenerations behaves like OnInterval but emits a generation
+	// number that starts at 1 and increases by one with each change. The loop
+	// never waits on a slow receiver: a pending generation is replaced by the
+	// next, so a gap between the generations received counts the changes
+	// that were coalesced.
+	OnIntervalGenerations(interval time.Duration) (<-chan uint64, context.CancelFunc)
+
+	// Changes returns a sequence of the target's changes, checking for them
+	// every interval, for use with range:
+	//
+	//	for change := range w.Changes(ctx, time.Second) {
+	//		...
+	//	}
+	//
+	// The target is checked from the ranging go routine, between iterations.
+	// The sequence ends once ctx is done or the watch is stopped.
+	Changes(ctx context.Context, interval time.Duration) iter.Seq[ChangeEvent]
+
+	// OnIntervalWithErrors behaves like OnInterval but also reports errors
+	// encountered while checking the target. Errors are dropped rather than
+	// stalling the watch if nobody is receiving them.
+	OnIntervalWithErrors(interval time.Duration) (<-chan struct{}, <-chan error, context.CancelFunc)
+
+	// OnChange calls fn from the watch go routine each time the target changes,
+	// checking for updates every interval until the returned cancel func is
+	// called. Unlike OnInterval's, the cancel func doesn't wait for the go
+	// routine to exit so that it may be called from fn.
+	OnChange(interval time.Duration, fn func()) context.CancelFunc
+
+	// OnChangeSync behaves like OnChange but passes fn the changed content and
+	// only takes it as the new baseline once fn returns nil. If fn returns an
+	// error the change is reported again on the next check, so that every
+	// change is handled at least once. Checks wait for fn to return.
+	OnChangeSync(interval time.Duration, fn func(content []byte) error) context.CancelFunc
+
+	// OnChangeErr behaves like OnChange but also reports check errors: fn is
+	// called with nil for each change and with the error for each failed check.
+	OnChangeErr(interval time.Duration, fn func(error)) context.CancelFunc
+
+	// OnIntervalDebounced behaves like OnInterval but only emits once the
+	// target has gone quiet long enough without changing, collapsing a burst
+	// of changes into a single signal.
+	OnIntervalDebounced(interval, quiet time.Duration) (<-chan struct{}, context.CancelFunc)
+
+	// OnIntervalThrottled behaves like OnInterval but never emits less than
+	// minGap apart. Changes detected within the gap are collapsed into a single
+	// signal sent once it has elapsed.
+	OnIntervalThrottled(interval, minGap time.Duration) (<-chan struct{}, context.CancelFunc)
+
+	// OnBecomes behaves like OnInterval but only emits when the target's
+	// content changes to satisfy predicate having not satisfied it before. A
+	// failed check of a target that fails open counts as content that doesn't
+	// satisfy it, so the next check that does emits again.
+	OnBecomes(interval time.Duration, predicate func([]byte) bool) (<-chan struct{}, context.CancelFunc)
+
+	// WaitForChange blocks until the target's content differs from what it was
+	// when called, checking every interval. It returns nil once a change is
+	// seen or ctx.Err() if ctx is done first.
+	WaitForChange(ctx context.Context, interval time.Duration) error
+
+	// Subscribe behaves like OnInterval except that every subscription with
+	// the same interval shares a single loop, so any number of subscribers
+	// cost one check of the target per interval. Each subscriber receives a
+	// signal after every change; signals are coalesced per subscriber so a
+	// slow one doesn't hold up the others. The loop stops once its last
+	// subscriber cancels.
+	Subscribe(interval time.Duration) (<-chan struct{}, context.CancelFunc)
+
+	// WaitUntilStable checks the target every interval until its content has
+	// gone at least stableFor without changing and returns that content, e.g.
+	// to wait for a config to settle. Failed checks don't count towards
+	// stability. It returns ctx.Err() if ctx is done first.
+	WaitUntilStable(ctx context.Context, interval, stableFor time.Duration) ([]byte, error)
+
+	// Snapshot returns the result of the most recent check made by Updated or
+	// any of the watch's loops: the content fetched, its hash, when the check
+	// was made, and the error if it failed. The target is checked now if it
+	// hasn't been yet. Calling Snapshot after receiving a signal yields the
+	// content that triggered it unless another check has happened since.
+	Snapshot() (content []byte, hash []byte, checkedAt time.Time, err error)
+
+	// Stats returns counters describing the checks made by Updated and all of
+	// the watch's loops.
+	Stats() WatchStats
+
+	// Start begins a watch loop like OnIntervalWithOpts and returns a Handle
+	// for controlling it.
+	Start(interval time.Duration, opts OnIntervalOpts) *Handle
+
+	// CurrentHash returns the hash of the content last reported by Updated, or
+	// nil if Updated has not yet succeeded. It is always nil for a watch made
+	// by NewAgainst, which has no such state.
+	CurrentHash() []byte
+
+	// SetHash seeds the state Updated compares against, e.g. with a hash
+	// previously returned by CurrentHash, so that unchanged content isn't
+	// reported as updated on the first call. It has no effect on a watch made
+	// by NewAgainst.
+	SetHash(hash []byte)
+
+	// Reset forgets the content last reported by Updated so that its next call
+	// reports the target as updated. Loops are reset with Handle.Reset. It has
+	// no effect on a watch made by NewAgainst.
+	Reset()
+
+	// Target returns the Watched the watch was constructed for.
+	Target() Watched
+
+	// Stop cancels every watch loop started from this Watch, including any
+	// started after Stop is called.
+	Stop()
 }
 
+// ChangeEvent describes a change detected by a watch loop.
+type ChangeEvent struct {
+	// OldHash is the hash of the previously seen content; it is empty for the
+	// first change a loop sees.
+	OldHash []byte
+
+	// NewHash is the hash of the content that triggered the change.
+	NewHash []byte
+
+	// OldSize and NewSize are the lengths of the previously seen content,
+	// zero for the first change, and of the content that triggered the
+	// change.
+	OldSize int
+	NewSize int
+
+	// At is when the change was detected.
+	At time.Time
+}
+
+// Grew reports whether the content got longer, e.g. a log being appended to.
+func (e ChangeEvent) Grew() bool { return e.NewSize > e.OldSize }
+
+// Shrank reports whether the content got shorter, e.g. a log being truncated
+// or rotated. A change that neither grew nor shrank the content replaced it
+// with content of the same length.
+func (e ChangeEvent) Shrank() bool { return e.NewSize < e.OldSize }
+
 // Watched is an interface representing an object that can be observed for
 // change
 type Watched interface {
 	// If we hit an error trying to get content this controls behavior of the
 	// Watch. Return true if the watch should treat content fetch errors as
-	// an update; false if it should not.
+	// an update; false if it should not. Implement ErrorClassifier to make
+	// this decision per error.
 	FailOpen() bool
 
 	// Content returns the content that should be compared or an error if it
@@ -37,9 +217,111 @@ type Watched interface {
 	Content() ([]byte, error)
 }
 
+// Identifier may be implemented by a Watched to describe what it watches,
+// e.g. a file's path or a URL. The targets in this package implement it where
+// they have such an identity.
+type Identifier interface {
+	Identity() string
+}
+
+// identity returns target's identity, or "" if it doesn't have one.
+func identity(target Watched) string {
+	if id, ok := target.(Identifier); ok {
+		return id.Identity()
+	}
+	return ""
+}
+
+// ContextWatched may be implemented by a Watched that does I/O so that checks
+// can be cancelled. When implemented the watch calls ContentCtx in place of
+// Content with a context that is done when the check times out (c.f.
+// WithCheckTimeout) or, for checks made by the watch's loops, the watch is
+// stopped.
+type ContextWatched interface {
+	Watched
+	ContentCtx(ctx context.Context) ([]byte, error)
+}
+
+// config is the configuration of a watch, as set by New and its Options.
+// Everything else in a watcher is state, so a watch with the same
+// configuration is made by copying it (c.f. Map).
+type config struct {
+	target Watched
+	hash   func([]byte) []byte
+
+	// baseline, if set, is the content Updated compares against in place of
+	// the last observed content
+	baseline []byte
+
+	observers []Observer
+	logger    Logger
+	clock     Clock
+
+	// checkTimeout, if positive, bounds each fetch of the target's content
+	checkTimeout time.Duration
+
+	// failed fetches are tried up to retryAttempts times in all, retryDelay
+	// apart
+	retryAttempts int
+	retryDelay    time.Duration
+
+	// content shorter than minLength is ignored rather than compared
+	minLength int
+
+	// normalizers are applied in order to content before it is hashed
+	normalizers []func([]byte) ([]byte, error)
+
+	// identity, if set, is hashed along with the content
+	identity string
+
+	// equals, if set, decides whether content has changed in place of
+	// comparing hashes
+	equals func(old, new []byte) bool
+
+	// events, if set, triggers checks in place of interval polling; the
+	// target is then polled every fallback, if positive, to catch any
+	// missed events
+	events   *notifier
+	fallback time.Duration
+
+	// prime seeds lastHash from the target at construction
+	prime bool
+}
+
 type watcher struct {
-	target   Watched
+	config
+
+	// rep is the content last found to differ by equals, which equal content
+	// takes the hash of
+	repMu sync.Mutex
+	rep   *snapshot
+
+	// stopCtx is cancelled by Stop; every watch loop derives from it
+	stopCtx context.Context
+	stop    context.CancelFunc
+
+	// primeErr holds the error from priming until it is reported by Updated
+	primeErr error
+
+	mu       sync.Mutex // guards lastHash and primeErr
 	lastHash []byte
+
+	lastMu sync.Mutex // guards last
+	last   snapshot
+
+	statsMu sync.Mutex // guards stats
+	stats   WatchStats
+
+	sharedMu sync.Mutex // guards shared
+	shared   map[time.Duration]*sharedLoop
+}
+
+// snapshot is the result of a check.
+type snapshot struct {
+	content   []byte
+	hash      []byte
+	checkedAt time.Time
+	err       error
 }
 
 var _ Watch = &watcher{}
@@ -49,78 +331,1088 @@ func File(path string) Watch {
 	return New(FileTarget(path, true))
 }
 
+// Dir constructs a Watch for a directory tree.
+func Dir(path string) Watch {
+	return New(DirectoryTarget(path, true, true))
+}
+
+// URL constructs a Watch for the content served at a URL.
+func URL(url string) Watch {
+	return New(URLTarget(url, true))
+}
+
 // New constructs a watch for a target.
-func New(target Watched) Watch {
-	return &watcher{target, nil}
+func New(target Watched, opts ...Option) Watch {
+	return newWatcher(target, opts...)
+}
+
+// NewAgainst constructs a watch for a target that compares content to a fixed
+// baseline rather than its previous value: Updated reports whether the current
+// content differs from the baseline, and OnInterval and friends emit when the
+// content drifts from, or returns to, the baseline. Since Updated always
+// compares against the baseline such a watch has no state of its own: its
+// CurrentHash is nil, SetHash and Reset have no effect, and SaveState has
+// nothing to save.
+func NewAgainst(target Watched, baseline []byte, opts ...Option) Watch {
+	// the baseline is set before priming so that it is checked against
+	return newWatcher(target, append(opts, func(w *watcher) {
+		w.baseline = append([]byte{}, baseline...)
+	})...)
+}
+
+// markers used in place of a content hash by watches made with NewAgainst
+var (
+	matchesBaseline     = []byte("matches baseline")
+	driftedFromBaseline = []byte("drifted from baseline")
+)
+
+func newWatcher(target Watched, opts ...Option) *watcher {
+	w := &watcher{config: config{target: target, hash: md5Hash, clock: realClock{}}}
+	w.stopCtx, w.stop = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.primeState()
+	return w
+}
+
+// primeState seeds lastHash if the watch was configured with WithPrimeState.
+func (w *watcher) primeState() {
+	if !w.prime {
+		return
+	}
+	if hash, _, err := w.check(context.Background()); err != nil {
+		w.primeErr = err
+	} else {
+		w.lastHash = hash
+	}
 }
 
 func (w *watcher) Updated() (bool, error) {
-	newHash, diff, err := w.targetDiff(w.lastHash)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.primeErr; err != nil {
+		w.primeErr = nil
+		return failOpen(w.target, err), err
+	}
+
+	if w.baseline != nil {
+		drifted, _, err := w.UpdatedSince(matchesBaseline)
+		return drifted, err
+	}
+
+	diff, newHash, err := w.UpdatedSince(w.lastHash)
 	if err == nil {
 		w.lastHash = newHash
 	}
 	return diff, err
 }
 
+func (w *watcher) UpdatedSince(token []byte) (bool, []byte, error) {
+	newToken, _, diff, err := w.targetDiff(context.Background(), token)
+	if err != nil {
+		return diff, token, err
+	}
+	return diff, newToken, nil
+}
+
 func (w *watcher) OnInterval(
 	interval time.Duration,
 ) (<-chan struct{}, context.CancelFunc) {
-	ticker := time.NewTicker(interval)
+	return w.onInterval(context.Background(), interval, OnIntervalOpts{})
+}
+
+func (w *watcher) OnIntervalCtx(
+	ctx context.Context,
+	interval time.Duration,
+) <-chan struct{} {
+	ch, _ := w.onInterval(ctx, interval, OnIntervalOpts{})
+	return ch
+}
+
+func (w *watcher) OnIntervalWithOpts(
+	interval time.Duration,
+	opts OnIntervalOpts,
+) (<-chan struct{}, context.CancelFunc) {
+	return w.onInterval(context.Background(), interval, opts)
+}
+
+func (w *watcher) onInterval(
+	parent context.Context,
+	interval time.Duration,
+	opts OnIntervalOpts,
+) (<-chan struct{}, context.CancelFunc) {
+	if opts.Coalesce {
+		ch := make(chan struct{}, 1)
+		cancelFn, exited := w.spawn(parent, func(done <-chan struct{}) {
+			defer close(ch)
+			// drop a signal nobody took before we were cancelled, like the
+			// unbuffered channel does
+			defer func() {
+				select {
+				case <-ch:
+				default:
+				}
+			}()
+			w.poll(interval, opts, done, func(change) bool {
+				// if a signal is already pending it covers this change too
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+				return true
+			}, nil)
+		})
+		return ch, joined(cancelFn, exited)
+	}
 
 	ch := make(chan struct{})
-	ctx, cancelFn := context.WithCancel(context.Background())
 
-	go func(ticker *time.Ticker, done <-chan struct{}, updatedCh chan<- struct{}) {
-		var lastHash []byte
-		cancelled := false
-		for !cancelled {
+	cancelFn, exited := w.spawn(parent, func(done <-chan struct{}) {
+		defer close(ch)
+		w.poll(interval, opts, done, func(change) bool {
 			select {
+			case ch <- struct{}{}:
+				return true
 			case <-done:
-				cancelled = true
-
-			case <-ticker.C:
-				if checkedHash, updated, err := w.targetDiff(lastHash); err == nil {
-					if updated {
-						lastHash = checkedHash
-						select {
-						case updatedCh <- struct{}{}:
-						case <-done:
-							cancelled = true
-						}
-					}
+				return false
+			}
+		}, nil)
+	})
+
+	return ch, joined(cancelFn, exited)
+}
+
+func (w *watcher) OnIntervalContent(
+	interval time.Duration,
+) (<-chan []byte, context.CancelFunc) {
+	ch := make(chan []byte)
+
+	cancelFn, exited := w.spawn(context.Background(), func(done <-chan struct{}) {
+		defer close(ch)
+		w.poll(interval, OnIntervalOpts{}, done, func(c change) bool {
+			select {
+			case ch <- c.content:
+				return true
+			case <-done:
+				return false
+			}
+		}, nil)
+	})
+
+	return ch, joined(cancelFn, exited)
+}
+
+func (w *watcher) OnIntervalEvents(
+	interval time.Duration,
+) (<-chan ChangeEvent, context.CancelFunc) {
+	ch := make(chan ChangeEvent)
+
+	cancelFn, exited := w.spawn(context.Background(), func(done <-chan struct{}) {
+		defer close(ch)
+		w.poll(interval, OnIntervalOpts{}, done, func(c change) bool {
+			select {
+			case ch <- c.event():
+				return true
+			case <-done:
+				return false
+			}
+		}, nil)
+	})
+
+	return ch, joined(cancelFn, exited)
+}
+
+func (w *watcher) OnIntervalGenerations(
+	interval time.Duration,
+) (<-chan uint64, context.CancelFunc) {
+	ch := make(chan uint64, 1)
+
+	cancelFn, exited := w.spawn(context.Background(), func(done <-chan struct{}) {
+		defer close(ch)
+		defer func() {
+			select {
+			case <-ch:
+			default:
+			}
+		}()
+
+		var generation uint64
+		w.poll(interval, OnIntervalOpts{}, done, func(change) bool {
+			generation++
+			select {
+			case ch <- generation:
+			default:
+				// replace the pending generation; we're the only sender so
+				// there is room once it's drained
+				select {
+				case <-ch:
+				default:
 				}
+				ch <- generation
 			}
-		}
-		close(updatedCh)
-	}(ticker, ctx.Done(), ch)
+			return true
+		}, nil)
+	})
 
-	return ch, cancelFn
+	return ch, joined(cancelFn, exited)
 }
 
-func (w *watcher) targetDiff(token []byte) ([]byte, bool, error) {
-	content, err := w.target.Content()
-	if err != nil {
-		return nil, w.target.FailOpen(), fmt.Errorf("Unable to get target content: %v", err.Error())
+func (w *watcher) Changes(ctx context.Context, interval time.Duration) iter.Seq[ChangeEvent] {
+	return func(yield func(ChangeEvent) bool) {
+		ctx, cancel := w.loopContext(ctx)
+		defer cancel()
+
+		w.poll(interval, OnIntervalOpts{}, ctx.Done(), func(c change) bool {
+			return yield(c.event())
+		}, nil)
 	}
+}
+
+func (w *watcher) OnIntervalWithErrors(
+	interval time.Duration,
+) (<-chan struct{}, <-chan error, context.CancelFunc) {
+	ch := make(chan struct{})
+	errCh := make(chan error, 1)
+
+	cancelFn, exited := w.spawn(context.Background(), func(done <-chan struct{}) {
+		defer close(errCh)
+		defer close(ch)
+		w.poll(interval, OnIntervalOpts{}, done, func(change) bool {
+			select {
+			case ch <- struct{}{}:
+				return true
+			case <-done:
+				return false
+			}
+		}, func(err error) {
+			select {
+			case errCh <- err:
+			default:
+			}
+		})
+	})
+
+	return ch, errCh, joined(cancelFn, exited)
+}
+
+func (w *watcher) OnChange(interval time.Duration, fn func()) context.CancelFunc {
+	cancelFn, _ := w.spawn(context.Background(), func(done <-chan struct{}) {
+		w.poll(interval, OnIntervalOpts{}, done, func(change) bool {
+			fn()
+			return true
+		}, nil)
+	})
+
+	return cancelFn
+}
+
+func (w *watcher) OnChangeSync(interval time.Duration, fn func([]byte) error) context.CancelFunc {
+	cancelFn, _ := w.spawn(context.Background(), func(done <-chan struct{}) {
+		var err error
+		opts := OnIntervalOpts{handled: func() bool { return err == nil }}
+		w.poll(interval, opts, done, func(c change) bool {
+			if err = fn(c.content); err != nil {
+				w.logf("watch: change handler failed, will retry: %v", err)
+			}
+			return true
+		}, nil)
+	})
+
+	return cancelFn
+}
+
+func (w *watcher) OnChangeErr(interval time.Duration, fn func(error)) context.CancelFunc {
+	cancelFn, _ := w.spawn(context.Background(), func(done <-chan struct{}) {
+		w.poll(interval, OnIntervalOpts{}, done, func(change) bool {
+			fn(nil)
+			return true
+		}, fn)
+	})
 
-	contentSum := md5.Sum(content)
-	// slicify for go
-	hash := contentSum[:]
+	return cancelFn
+}
+
+func (w *watcher) OnBecomes(
+	interval time.Duration,
+	predicate func([]byte) bool,
+) (<-chan struct{}, context.CancelFunc) {
+	ch := make(chan struct{})
+
+	cancelFn, exited := w.spawn(context.Background(), func(done <-chan struct{}) {
+		defer close(ch)
+
+		satisfied := false
+		opts := OnIntervalOpts{forgetOnFailOpen: true}
+		w.poll(interval, opts, done, func(c change) bool {
+			was := satisfied
+			satisfied = predicate(c.content)
+			if was || !satisfied {
+				return true
+			}
+			select {
+			case ch <- struct{}{}:
+				return true
+			case <-done:
+				return false
+			}
+		}, func(err error) {
+			if failOpen(w.target, err) {
+				satisfied = false
+			}
+		})
+	})
+
+	return ch, joined(cancelFn, exited)
+}
+
+func (w *watcher) OnIntervalDebounced(
+	interval, quiet time.Duration,
+) (<-chan struct{}, context.CancelFunc) {
+	ch := make(chan struct{})
+
+	cancelFn, exited := w.spawn(context.Background(), func(done <-chan struct{}) {
+		changes, polled := w.pollChanges(interval, done)
+		defer func() { <-polled }()
+		defer close(ch)
+
+		var timer Timer
+		var settled <-chan time.Time
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-done:
+				return
+
+			case <-changes:
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = w.clock.NewTimer(quiet)
+				settled = timer.C()
 
-	if byteSliceMatch(hash, token) {
-		return token, false, nil
+			case <-settled:
+				settled = nil
+				select {
+				case ch <- struct{}{}:
+				case <-done:
+					return
+				}
+			}
+		}
+	})
+
+	return ch, joined(cancelFn, exited)
+}
+
+func (w *watcher) OnIntervalThrottled(
+	interval, minGap time.Duration,
+) (<-chan struct{}, context.CancelFunc) {
+	ch := make(chan struct{})
+
+	cancelFn, exited := w.spawn(context.Background(), func(done <-chan struct{}) {
+		changes, polled := w.pollChanges(interval, done)
+		defer func() { <-polled }()
+		defer close(ch)
+
+		var lastEmit time.Time
+		var timer Timer
+		var pending <-chan time.Time
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		emit := func() bool {
+			select {
+			case ch <- struct{}{}:
+				lastEmit = w.clock.Now()
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-done:
+				return
+
+			case <-changes:
+				if pending != nil {
+					// already due to emit at the end of this window
+					continue
+				}
+				if wait := minGap - w.clock.Now().Sub(lastEmit); wait > 0 {
+					timer = w.clock.NewTimer(wait)
+					pending = timer.C()
+					continue
+				}
+				if !emit() {
+					return
+				}
+
+			case <-pending:
+				pending = nil
+				if !emit() {
+					return
+				}
+			}
+		}
+	})
+
+	return ch, joined(cancelFn, exited)
+}
+
+func (w *watcher) WaitUntilStable(
+	ctx context.Context,
+	interval, stableFor time.Duration,
+) ([]byte, error) {
+	ctx, cancelFn := w.loopContext(ctx)
+	defer cancelFn()
+
+	timer := w.clock.NewTimer(interval)
+	defer timer.Stop()
+
+	var token, content []byte
+	var since time.Time
+	for {
+		hash, checked, changed, err := w.targetDiff(ctx, token)
+		switch {
+		case err != nil:
+			// we don't know what the content is so start over
+			token, since = nil, time.Time{}
+		case changed:
+			token, content, since = hash, checked, w.clock.Now()
+		case !since.IsZero() && w.clock.Now().Sub(since) >= stableFor:
+			// only once a check has established the content; ignored content
+			// (c.f. WithMinLength) doesn't
+			return content, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C():
+			timer.Reset(interval)
+		}
 	}
-	return hash, true, nil
 }
 
-func byteSliceMatch(a, b []byte) bool {
-	if len(a) != len(b) {
+func (w *watcher) WaitForChange(ctx context.Context, interval time.Duration) error {
+	ctx, cancelFn := w.loopContext(ctx)
+	defer cancelFn()
+
+	changed := false
+	w.poll(interval, OnIntervalOpts{prime: true}, ctx.Done(), func(change) bool {
+		changed = true
 		return false
+	}, nil)
+
+	if changed {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// pollChanges starts polling the target in a new go routine and returns a
+// channel that is signaled, coalescing, for each detected change along with a
+// channel that is closed once polling stops.
+func (w *watcher) pollChanges(
+	interval time.Duration,
+	done <-chan struct{},
+) (<-chan struct{}, <-chan struct{}) {
+	changes := make(chan struct{}, 1)
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		w.poll(interval, OnIntervalOpts{}, done, func(change) bool {
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+			return true
+		}, nil)
+	}()
+
+	return changes, exited
+}
+
+func (w *watcher) CurrentHash() []byte {
+	if w.baseline != nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]byte(nil), w.lastHash...)
+}
+
+func (w *watcher) Reset() { w.SetHash(nil) }
+
+func (w *watcher) SetHash(hash []byte) {
+	if w.baseline != nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastHash = append([]byte(nil), hash...)
+}
+
+func (w *watcher) Target() Watched { return w.target }
+
+func (w *watcher) Stop() { w.stop() }
+
+// loopContext derives the context for a new watch loop from parent such that
+// it is also cancelled by Stop.
+func (w *watcher) loopContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	release := context.AfterFunc(w.stopCtx, cancel)
+	return ctx, func() {
+		release()
+		cancel()
+	}
+}
+
+// spawn runs fn in a new go routine with a context derived from parent (c.f.
+// loopContext), returning the context's cancel func and a channel that is
+// closed once fn has returned.
+func (w *watcher) spawn(
+	parent context.Context,
+	fn func(done <-chan struct{}),
+) (context.CancelFunc, <-chan struct{}) {
+	ctx, cancelFn := w.loopContext(parent)
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		defer cancelFn()
+		fn(ctx.Done())
+	}()
+
+	return cancelFn, exited
+}
+
+// joined returns a cancel func that calls cancelFn and then waits for exited
+// to be closed.
+func joined(cancelFn context.CancelFunc, exited <-chan struct{}) context.CancelFunc {
+	return func() {
+		cancelFn()
+		<-exited
+	}
+}
+
+// change describes a change detected by poll.
+type change struct {
+	oldHash []byte
+	newHash []byte
+	oldSize int
+	content []byte
+	at      time.Time
+}
+
+func (c change) event() ChangeEvent {
+	return ChangeEvent{
+		OldHash: c.oldHash,
+		NewHash: c.newHash,
+		OldSize: c.oldSize,
+		NewSize: len(c.content),
+		At:      c.at,
+	}
+}
+
+// poll checks the target every interval until done is closed, calling emit
+// for each detected change. The loop also stops if emit returns false. If onErr
+// is non-nil it is called with any error hit while checking the target.
+//
+// Event driven watches check the target on each event instead, polling only at
+// their fallback interval if they have one.
+func (w *watcher) poll(
+	interval time.Duration,
+	opts OnIntervalOpts,
+	done <-chan struct{},
+	emit func(change) bool,
+	onErr func(error),
+) {
+	if opts.handle != nil {
+		defer close(opts.handle.exited)
+	}
+
+	var events <-chan struct{}
+	if w.events != nil {
+		ch, unsubscribe := w.events.subscribe()
+		defer unsubscribe()
+		events = ch
+		// event driven watches only poll as a safety net, if at all
+		interval = w.fallback
+	}
+
+	delay := interval
+
+	var timer Timer
+	var ticks <-chan time.Time
+	if delay > 0 {
+		timer = w.clock.NewTimer(opts.wait(w.clock.Now(), delay))
+		defer timer.Stop()
+		ticks = timer.C()
+	}
+
+	var resumed, checkNow, reset <-chan struct{}
+	if opts.handle != nil {
+		resumed = opts.handle.resumed
+		checkNow = opts.handle.check
+		reset = opts.handle.reset
 	}
-	for i := range a {
-		if a[i] != b[i] {
+
+	var lastHash []byte
+	var lastSize int
+	recent := newHashRing(opts.Dedup)
+	failures := 0
+
+	// rebaseline takes the target's current state as the baseline without
+	// reporting it
+	rebaseline := func() {
+		if checkedHash, content, err := w.observe(w.stopCtx); err == nil {
+			lastHash, lastSize = checkedHash, len(content)
+		}
+	}
+
+	// tick checks the target once and reports whether polling should continue
+	tick := func() bool {
+		if opts.Active != nil && !opts.Active(w.clock.Now()) {
+			return true
+		}
+
+		checkedHash, content, updated, err := w.targetDiff(w.stopCtx, lastHash)
+		if err != nil {
+			if updated && opts.forgetOnFailOpen {
+				lastHash = nil
+			}
+			delay = opts.backoff(delay)
+			if onErr != nil {
+				onErr(err)
+			}
+			failures++
+			if opts.MaxConsecutiveErrors > 0 && failures >= opts.MaxConsecutiveErrors {
+				opts.handle.setErr(err)
+				return false
+			}
+			return true
+		}
+		delay = interval
+		failures = 0
+
+		flapping := recent.contains(checkedHash)
+		recent.add(checkedHash)
+		if !updated {
+			return true
+		}
+		if flapping {
+			// returned to a recently seen state; take it as the new baseline
+			// without reporting it
+			lastHash, lastSize = checkedHash, len(content)
+			return true
+		}
+
+		c := change{
+			oldHash: lastHash,
+			newHash: checkedHash,
+			oldSize: lastSize,
+			content: content,
+			at:      w.clock.Now(),
+		}
+		lastHash, lastSize = checkedHash, len(content)
+		// don't emit if we were cancelled while checking the target
+		select {
+		case <-done:
+			return false
+		default:
+		}
+		if !emit(c) {
 			return false
 		}
+		if opts.handled != nil && !opts.handled() {
+			// restore the old baseline so the next check reports it again
+			lastHash, lastSize = c.oldHash, c.oldSize
+			return true
+		}
+
+		if opts.Cooldown > 0 {
+			cooldown := w.clock.NewTimer(opts.Cooldown)
+			select {
+			case <-done:
+				cooldown.Stop()
+				return false
+			case <-cooldown.C():
+			}
+			rebaseline()
+		}
+		return true
+	}
+
+	if opts.prime {
+		rebaseline()
+	} else if opts.EmitInitial && !tick() {
+		return
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case <-ticks:
+			if !opts.handle.isPaused() && !tick() {
+				return
+			}
+			timer.Reset(opts.wait(w.clock.Now(), delay))
+
+		case <-events:
+			if !opts.handle.isPaused() && !tick() {
+				return
+			}
+
+		case <-resumed:
+			// catch up on anything that changed while we were paused
+			if !opts.handle.isPaused() && !tick() {
+				return
+			}
+
+		case <-checkNow:
+			if !opts.handle.isPaused() && !tick() {
+				return
+			}
+
+		case <-reset:
+			lastHash, lastSize = nil, 0
+			recent = newHashRing(opts.Dedup)
+			if !opts.handle.isPaused() && !tick() {
+				return
+			}
+		}
+	}
+}
+
+func (w *watcher) targetDiff(ctx context.Context, token []byte) ([]byte, []byte, bool, error) {
+	for _, o := range w.observers {
+		o.OnCheck()
+	}
+
+	hash, content, fetched, err := w.timedCheck(ctx)
+	w.record(hash, content, err)
+
+	changed, err := w.diff(token, hash, content, err)
+	w.count(changed, err)
+	for _, o := range w.observers {
+		if lo, ok := o.(LatencyObserver); ok {
+			lo.OnCheckDone(fetched, changed)
+		}
+	}
+	if err != nil {
+		return nil, nil, changed, err
+	}
+	if !changed {
+		return token, content, false, nil
+	}
+	return hash, content, true, nil
+}
+
+// diff reports whether a check's result counts as a change from token.
+func (w *watcher) diff(token, hash, content []byte, err error) (bool, error) {
+	if err != nil {
+		for _, o := range w.observers {
+			o.OnError(err)
+		}
+		open := failOpen(w.target, err)
+		w.logf("watch: check failed (fail open: %v): %v", open, err)
+		return open, err
+	}
+
+	if len(content) < w.minLength {
+		// most likely caught a writer part way through; wait for it to finish
+		w.logf("watch: ignoring %d bytes of content, minimum is %d", len(content), w.minLength)
+		return false, nil
+	}
+
+	if bytes.Equal(hash, token) {
+		w.logf("watch: unchanged (hash %x)", hash)
+		return false, nil
+	}
+
+	for _, o := range w.observers {
+		o.OnChange()
+	}
+	w.logf("watch: changed (hash %x -> %x)", token, hash)
+	return true, nil
+}
+
+func (w *watcher) Explain() (bool, string, error) {
+	w.mu.Lock()
+	token, primeErr := w.lastHash, w.primeErr
+	w.mu.Unlock()
+
+	changed, reason, err := w.explain(token)
+	if primeErr != nil {
+		// the target may have recovered since but Updated reports the
+		// failure before checking again
+		reason = fmt.Sprintf("priming check failed (fail open: %v) and is reported by the next Updated: %v; currently %s",
+			failOpen(w.target, primeErr), primeErr, reason)
+	}
+	return changed, reason, err
+}
+
+// explain does the work of Explain for a watch that last reported token.
+func (w *watcher) explain(token []byte) (bool, string, error) {
+	w.lastMu.Lock()
+	prev := w.last
+	w.lastMu.Unlock()
+
+	hash, content, err := w.check(context.Background())
+	if err != nil {
+		open := failOpen(w.target, err)
+		return open, fmt.Sprintf("check failed (fail open: %v)", open), err
+	}
+
+	if len(content) < w.minLength {
+		return false, fmt.Sprintf("ignoring %d bytes of content, minimum is %d", len(content), w.minLength), nil
+	}
+
+	if w.baseline != nil {
+		if bytes.Equal(hash, matchesBaseline) {
+			return false, "content matches baseline", nil
+		}
+		return true, "content differs from baseline", nil
+	}
+
+	if token == nil {
+		return true, "no previous content to compare against", nil
+	}
+	if !bytes.Equal(hash, token) {
+		return true, fmt.Sprintf("hash changed (%x -> %x)", token, hash), nil
+	}
+	if (len(w.normalizers) > 0 || w.equals != nil) &&
+		prev.err == nil && bytes.Equal(prev.hash, token) && !bytes.Equal(prev.content, content) {
+		return false, "content changed since the last check but normalizers or WithEquals deem it unchanged", nil
+	}
+	return false, fmt.Sprintf("hash unchanged (%x)", hash), nil
+}
+
+// observe checks the target and records the result for Snapshot without
+// comparing it to anything, so the check isn't counted, observed or logged as
+// a change.
+func (w *watcher) observe(ctx context.Context) ([]byte, []byte, error) {
+	hash, content, err := w.check(ctx)
+	w.record(hash, content, err)
+	return hash, content, err
+}
+
+// record saves the result of a check for Snapshot.
+func (w *watcher) record(hash, content []byte, err error) {
+	w.lastMu.Lock()
+	defer w.lastMu.Unlock()
+	w.last = snapshot{content, hash, w.clock.Now(), err}
+}
+
+func (w *watcher) Snapshot() ([]byte, []byte, time.Time, error) {
+	w.lastMu.Lock()
+	last := w.last
+	w.lastMu.Unlock()
+
+	if last.checkedAt.IsZero() {
+		w.observe(context.Background())
+		w.lastMu.Lock()
+		last = w.last
+		w.lastMu.Unlock()
+	}
+	return last.content, last.hash, last.checkedAt, last.err
+}
+
+func (w *watcher) logf(format string, args ...interface{}) {
+	if w.logger != nil {
+		w.logger.Printf(format, args...)
+	}
+}
+
+// check fetches the target's current content and its hash. The hash is taken
+// after normalization but the content is returned as fetched.
+//
+// Watches made by NewAgainst don't return the content's hash but a marker of
+// whether it matches the baseline, so that it is only reported as changed when
+// it drifts from or returns to the baseline.
+//
+// ctx is passed on to the target if it can be cancelled (c.f. content).
+func (w *watcher) check(ctx context.Context) ([]byte, []byte, error) {
+	hash, content, _, err := w.timedCheck(ctx)
+	return hash, content, err
+}
+
+// timedCheck behaves like check but also returns how long fetching the
+// target's content took.
+func (w *watcher) timedCheck(ctx context.Context) ([]byte, []byte, time.Duration, error) {
+	start := time.Now()
+	content, err := w.content(ctx)
+	fetched := time.Since(start)
+	if err != nil {
+		return nil, nil, fetched, contentErr(w.target, err)
+	}
+
+	if w.baseline != nil {
+		matches, err := w.isBaseline(content)
+		if err != nil {
+			return nil, nil, fetched, checkErr(w.target, err)
+		}
+		if matches {
+			return matchesBaseline, content, fetched, nil
+		}
+		return driftedFromBaseline, content, fetched, nil
+	}
+
+	hash, err := w.digest(content)
+	if err != nil {
+		return nil, nil, fetched, checkErr(w.target, err)
+	}
+	if w.equals != nil {
+		hash = w.representative(hash, content)
+	}
+	return hash, content, fetched, nil
+}
+
+func (w *watcher) isBaseline(content []byte) (bool, error) {
+	if w.equals != nil {
+		return w.equals(w.baseline, content), nil
+	}
+
+	hash, err := w.digest(content)
+	if err != nil {
+		return false, err
+	}
+	baseHash, err := w.digest(w.baseline)
+	if err != nil {
+		return false, fmt.Errorf("Unable to digest baseline: %w", err)
+	}
+	return bytes.Equal(hash, baseHash), nil
+}
+
+// representative returns the hash of the most recently seen content if it is
+// equal to content by w.equals, so that equal content is reported as
+// unchanged. Otherwise content becomes the one later content is compared to.
+func (w *watcher) representative(hash, content []byte) []byte {
+	w.repMu.Lock()
+	defer w.repMu.Unlock()
+
+	if w.rep != nil && (bytes.Equal(hash, w.rep.hash) || w.equals(w.rep.content, content)) {
+		return w.rep.hash
+	}
+	w.rep = &snapshot{content: append([]byte(nil), content...), hash: hash}
+	return hash
+}
+
+// content fetches the target's content, retrying failures as configured by
+// WithRetry and giving up once the check timeout has elapsed. Targets that can
+// be cancelled also are once ctx is done: watch loops pass stopCtx so that
+// Stop cancels their checks, while direct checks such as Updated pass a
+// background context so that they still work after Stop.
+func (w *watcher) content(ctx context.Context) ([]byte, error) {
+	if _, ok := w.target.(ContextWatched); ok {
+		ctx = withClock(ctx, w.clock)
+	} else {
+		ctx = context.Background()
+	}
+	cancel := context.CancelFunc(func() {})
+	if w.checkTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, w.checkTimeout)
+	}
+	defer cancel()
+
+	content, err := w.fetch(ctx)
+	for attempt := 1; err != nil && attempt < w.retryAttempts; attempt++ {
+		if ctx.Err() != nil {
+			// out of time for retrying; report the last failure as timing out
+			if !errors.Is(err, ErrCheckTimeout) {
+				err = w.abandoned(ctx, err)
+			}
+			break
+		}
+		w.logf("watch: retrying failed fetch (attempt %d of %d): %v", attempt+1, w.retryAttempts, err)
+
+		timer := w.clock.NewTimer(w.retryDelay)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, w.abandoned(ctx, err)
+		}
+		content, err = w.fetch(ctx)
+	}
+	return content, err
+}
+
+// fetch makes a single attempt at fetching the target's content. A target that
+// is given up on once ctx is done is left to finish in the background unless
+// it implements ContextWatched, in which case it is passed ctx.
+func (w *watcher) fetch(ctx context.Context) ([]byte, error) {
+	if cw, ok := w.target.(ContextWatched); ok {
+		content, err := cw.ContentCtx(ctx)
+		if err != nil {
+			return nil, w.abandoned(ctx, err)
+		}
+		return content, nil
+	}
+
+	if w.checkTimeout <= 0 {
+		return w.target.Content()
+	}
+
+	type result struct {
+		content []byte
+		err     error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		content, err := w.target.Content()
+		ch <- result{content, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.content, r.err
+	case <-ctx.Done():
+		return nil, w.abandoned(ctx, nil)
+	}
+}
+
+// abandoned annotates err, the most recent failure if any, as having timed out
+// if the check timeout has elapsed.
+func (w *watcher) abandoned(ctx context.Context, err error) error {
+	if ctx.Err() != context.DeadlineExceeded {
+		if err == nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	if err == nil {
+		return fmt.Errorf("%w after %v", ErrCheckTimeout, w.checkTimeout)
+	}
+	return fmt.Errorf("%w after %v: %w", ErrCheckTimeout, w.checkTimeout, err)
+}
+
+// digest normalizes and hashes content.
+func (w *watcher) digest(content []byte) ([]byte, error) {
+	var err error
+	for _, normalize := range w.normalizers {
+		if content, err = normalize(content); err != nil {
+			return nil, fmt.Errorf("Unable to normalize target content: %w", err)
+		}
+	}
+	if w.identity != "" {
+		content = append([]byte(w.identity+"\x00"), content...)
 	}
-	return true
+	return w.hash(content), nil
 }
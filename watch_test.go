@@ -0,0 +1,340 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/falun/watch"
+	"github.com/falun/watch/watchtest"
+)
+
+func TestUpdatedConcurrent(t *testing.T) {
+	const callers = 32
+
+	fake := watchtest.NewFake([]byte("initial"))
+	w := watch.New(fake)
+
+	for round := 0; round < 20; round++ {
+		fake.SetContent([]byte(fmt.Sprintf("round %d", round)))
+
+		var updated atomic.Int32
+		var start, wg sync.WaitGroup
+		start.Add(1)
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				start.Wait()
+				changed, err := w.Updated()
+				if err != nil {
+					t.Error(err)
+				}
+				if changed {
+					updated.Add(1)
+				}
+			}()
+		}
+		start.Done()
+		wg.Wait()
+
+		if n := updated.Load(); n != 1 {
+			t.Fatalf("round %d: change reported to %d callers, want 1", round, n)
+		}
+	}
+}
+
+func TestCancelLeavesNoGoroutines(t *testing.T) {
+	const interval = time.Millisecond
+
+	// each variant starts a loop and returns a func that reports whether its
+	// channel is closed, along with the loop's cancel func
+	variants := map[string]func(w watch.Watch) (func() bool, context.CancelFunc){
+		"OnInterval": func(w watch.Watch) (func() bool, context.CancelFunc) {
+			ch, cancel := w.OnInterval(interval)
+			return func() bool { _, ok := <-ch; return !ok }, cancel
+		},
+		"OnIntervalContent": func(w watch.Watch) (func() bool, context.CancelFunc) {
+			ch, cancel := w.OnIntervalContent(interval)
+			return func() bool { _, ok := <-ch; return !ok }, cancel
+		},
+		"OnIntervalEvents": func(w watch.Watch) (func() bool, context.CancelFunc) {
+			ch, cancel := w.OnIntervalEvents(interval)
+			return func() bool { _, ok := <-ch; return !ok }, cancel
+		},
+		"OnIntervalWithErrors": func(w watch.Watch) (func() bool, context.CancelFunc) {
+			ch, errCh, cancel := w.OnIntervalWithErrors(interval)
+			return func() bool {
+				_, ok := <-ch
+				_, errOK := <-errCh
+				return !ok && !errOK
+			}, cancel
+		},
+		"OnIntervalDebounced": func(w watch.Watch) (func() bool, context.CancelFunc) {
+			ch, cancel := w.OnIntervalDebounced(interval, interval)
+			return func() bool { _, ok := <-ch; return !ok }, cancel
+		},
+		"OnIntervalThrottled": func(w watch.Watch) (func() bool, context.CancelFunc) {
+			ch, cancel := w.OnIntervalThrottled(interval, interval)
+			return func() bool { _, ok := <-ch; return !ok }, cancel
+		},
+		"OnIntervalGenerations": func(w watch.Watch) (func() bool, context.CancelFunc) {
+			ch, cancel := w.OnIntervalGenerations(interval)
+			return func() bool {
+				// a coalesced generation may be pending; it is dropped on
+				// cancel
+				_, ok := <-ch
+				return !ok
+			}, cancel
+		},
+	}
+
+	for name, start := range variants {
+		t.Run(name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+
+			var n atomic.Int64
+			target := watch.FuncTarget(func() ([]byte, error) {
+				// a change on every check keeps the loop emitting
+				return []byte(fmt.Sprint(n.Add(1))), nil
+			}, false)
+			closed, cancel := start(watch.New(target))
+			time.Sleep(10 * interval)
+			cancel()
+
+			if !closed() {
+				t.Fatal("channel not closed after cancel")
+			}
+
+			// the loop has exited by the time cancel returns but the runtime
+			// may not have finished tearing its go routines down
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if after := runtime.NumGoroutine(); after > before {
+				t.Errorf("%d go routines running after cancel, %d before start", after, before)
+			}
+		})
+	}
+}
+
+func TestNewAgainstPrimeError(t *testing.T) {
+	fake := watchtest.NewFake(nil)
+	fake.SetError(errors.New("unavailable"))
+	w := watch.NewAgainst(fake, []byte("baseline"), watch.WithPrimeState(true))
+
+	fake.SetContent([]byte("baseline"))
+	if _, err := w.Updated(); err == nil {
+		t.Fatal("priming failure not reported by the first Updated")
+	}
+	changed, err := w.Updated()
+	if err != nil || changed {
+		t.Errorf("Updated() = %v, %v after priming failure was reported; want false, nil", changed, err)
+	}
+}
+
+func TestCancelWithPendingEmit(t *testing.T) {
+	for name, opts := range map[string]watch.OnIntervalOpts{
+		"unbuffered": {EmitInitial: true},
+		"coalesced":  {EmitInitial: true, Coalesce: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				var n atomic.Int64
+				target := watch.FuncTarget(func() ([]byte, error) {
+					return []byte(fmt.Sprint(n.Add(1))), nil
+				}, false)
+				ch, cancel := watch.New(target).OnIntervalWithOpts(time.Millisecond, opts)
+
+				// race a receiver against cancel while a signal is pending
+				received := make(chan struct{})
+				go func() {
+					defer close(received)
+					for range ch {
+					}
+				}()
+				if i%2 == 0 {
+					time.Sleep(time.Duration(i%5) * time.Millisecond)
+				}
+
+				cancelled := make(chan struct{})
+				go func() {
+					defer close(cancelled)
+					cancel()
+				}()
+				select {
+				case <-cancelled:
+				case <-time.After(time.Second):
+					t.Fatal("cancel blocked on a pending signal")
+				}
+
+				select {
+				case <-received:
+				case <-time.After(time.Second):
+					t.Fatal("channel not closed after cancel")
+				}
+			}
+		})
+	}
+}
+
+func TestCancelDropsPendingSignal(t *testing.T) {
+	fake := watchtest.NewFake([]byte("content"))
+	w := watch.New(fake)
+	ch, cancel := w.OnIntervalWithOpts(time.Hour, watch.OnIntervalOpts{EmitInitial: true, Coalesce: true})
+
+	// wait for the initial signal to be pending
+	deadline := time.Now().Add(time.Second)
+	for w.Stats().Checks == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if _, ok := <-ch; ok {
+		t.Error("signal pending at cancel was delivered")
+	}
+}
+
+func TestUpdatedSinceTokens(t *testing.T) {
+	// hash content as itself so that tokens are the content
+	identity := watch.WithHash(func(content []byte) []byte { return content })
+
+	for _, tc := range []struct {
+		name    string
+		token   []byte
+		changed bool
+	}{
+		{"equal", []byte("abc"), false},
+		{"unequal", []byte("abd"), true},
+		{"shorter", []byte("ab"), true},
+		{"longer", []byte("abcd"), true},
+		{"empty", []byte{}, true},
+		{"nil", nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := watch.New(watchtest.NewFake([]byte("abc")), identity)
+
+			changed, token, err := w.UpdatedSince(tc.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tc.changed {
+				t.Errorf("UpdatedSince(%q) changed = %v, want %v", tc.token, changed, tc.changed)
+			}
+			if string(token) != "abc" {
+				t.Errorf("UpdatedSince(%q) token = %q, want %q", tc.token, token, "abc")
+			}
+		})
+	}
+}
+
+func TestExplainPendingPrimeError(t *testing.T) {
+	fake := watchtest.NewFake(nil)
+	fake.SetError(errors.New("unavailable"))
+	w := watch.New(fake, watch.WithPrimeState(true))
+
+	fake.SetContent([]byte("recovered"))
+	calls := fake.Calls()
+	changed, reason, err := w.Explain()
+	if fake.Calls() == calls {
+		t.Error("Explain didn't check the target")
+	}
+	if err != nil || !changed {
+		t.Errorf("Explain() = %v, %q, %v; want true, nil once the target recovered", changed, reason, err)
+	}
+	if !strings.Contains(reason, "priming check failed") {
+		t.Errorf("Explain() reason %q doesn't mention the pending priming failure", reason)
+	}
+}
+
+func TestWaitUntilStable(t *testing.T) {
+	w := watch.New(watchtest.NewFake([]byte("settled")))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	content, err := w.WaitUntilStable(ctx, time.Millisecond, 5*time.Millisecond)
+	if err != nil || string(content) != "settled" {
+		t.Errorf("WaitUntilStable() = %q, %v; want %q, nil", content, err, "settled")
+	}
+}
+
+func TestWaitUntilStableIgnoredContent(t *testing.T) {
+	w := watch.New(watchtest.NewFake([]byte("ab")), watch.WithMinLength(10))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	content, err := w.WaitUntilStable(ctx, time.Millisecond, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitUntilStable() = %q, %v for content that is always ignored; want a deadline error", content, err)
+	}
+}
+
+// countingObserver counts the changes it is notified of.
+type countingObserver struct {
+	changes atomic.Int32
+}
+
+func (o *countingObserver) OnCheck()      {}
+func (o *countingObserver) OnChange()     { o.changes.Add(1) }
+func (o *countingObserver) OnError(error) {}
+
+func TestWaitForChangeBaselineNotCounted(t *testing.T) {
+	var o countingObserver
+	w := watch.New(watchtest.NewFake([]byte("content")), watch.WithObserver(&o))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.WaitForChange(ctx, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForChange() = %v, want a deadline error", err)
+	}
+
+	if stats := w.Stats(); stats.Changes != 0 || !stats.LastChange.IsZero() {
+		t.Errorf("Stats() = %+v after no change, want no changes", stats)
+	}
+	if n := o.changes.Load(); n != 0 {
+		t.Errorf("OnChange called %d times after no change", n)
+	}
+}
+
+func TestSnapshotDoesNotCount(t *testing.T) {
+	var o countingObserver
+	w := watch.New(watchtest.NewFake([]byte("content")), watch.WithObserver(&o))
+
+	content, _, _, err := w.Snapshot()
+	if err != nil || string(content) != "content" {
+		t.Fatalf("Snapshot() = %q, %v; want %q, nil", content, err, "content")
+	}
+	if stats := w.Stats(); stats.Checks != 0 || stats.Changes != 0 {
+		t.Errorf("Stats() = %+v after Snapshot, want nothing counted", stats)
+	}
+	if n := o.changes.Load(); n != 0 {
+		t.Errorf("OnChange called %d times by Snapshot", n)
+	}
+	if changed, _ := w.Updated(); !changed {
+		t.Error("Snapshot consumed the first change reported by Updated")
+	}
+}
+
+func TestNewAgainstHasNoHashState(t *testing.T) {
+	fake := watchtest.NewFake([]byte("drifted"))
+	w := watch.NewAgainst(fake, []byte("baseline"), watch.WithPrimeState(true))
+
+	w.SetHash([]byte("seeded"))
+	if changed, err := w.Updated(); err != nil || !changed {
+		t.Errorf("Updated() = %v, %v for drifted content; want true, nil", changed, err)
+	}
+	if hash := w.CurrentHash(); hash != nil {
+		t.Errorf("CurrentHash() = %x, want nil", hash)
+	}
+	w.Reset()
+	if changed, _ := w.Updated(); !changed {
+		t.Error("drifted content not reported after Reset")
+	}
+}
@@ -0,0 +1,148 @@
+// Package watchhttp provides an http.Handler that reports the state of the
+// watches in a watch.Registry, for mounting as an operational debug endpoint.
+package watchhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/falun/watch"
+)
+
+// Status describes a registered watch as rendered by the handler.
+type Status struct {
+	Name   string `json:"name"`
+	Target string `json:"target,omitempty"`
+
+	// Running reports whether the watch was registered with a Handle, and so
+	// can be asked to check now.
+	Running bool `json:"running"`
+
+	Checks            uint64     `json:"checks"`
+	Changes           uint64     `json:"changes"`
+	Errors            uint64     `json:"errors"`
+	ConsecutiveErrors int        `json:"consecutive_errors"`
+	LastCheck         *time.Time `json:"last_check,omitempty"`
+	LastChange        *time.Time `json:"last_change,omitempty"`
+	LastError         string     `json:"last_error,omitempty"`
+
+	// Stopped holds the error that stopped the watch's loop, if any (c.f.
+	// watch.Handle.Err).
+	Stopped string `json:"stopped,omitempty"`
+}
+
+type handler struct {
+	registry *watch.Registry
+}
+
+// Handler returns an http.Handler for the watches in r. A GET responds with a
+// JSON array of each watch's Status, or with just one watch's when the request
+// has a name query parameter. A POST asks the named watch, or every running
+// watch if no name is given, to check its target right away and responds with
+// the names of the watches asked.
+func Handler(r *watch.Registry) http.Handler {
+	return handler{r}
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		h.status(w, req)
+	case http.MethodPost:
+		h.checkNow(w, req)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h handler) status(w http.ResponseWriter, req *http.Request) {
+	if name := req.URL.Query().Get("name"); name != "" {
+		e, ok := h.registry.Lookup(name)
+		if !ok {
+			notFound(w, name)
+			return
+		}
+		respond(w, http.StatusOK, status(e))
+		return
+	}
+
+	statuses := []Status{}
+	for _, e := range h.registry.Watches() {
+		statuses = append(statuses, status(e))
+	}
+	respond(w, http.StatusOK, statuses)
+}
+
+func (h handler) checkNow(w http.ResponseWriter, req *http.Request) {
+	var watches []watch.Registered
+	if name := req.URL.Query().Get("name"); name != "" {
+		e, ok := h.registry.Lookup(name)
+		if !ok {
+			notFound(w, name)
+			return
+		}
+		if e.Handle == nil {
+			http.Error(w, fmt.Sprintf("Watch %q is not running", name), http.StatusConflict)
+			return
+		}
+		watches = append(watches, e)
+	} else {
+		watches = h.registry.Watches()
+	}
+
+	checked := []string{}
+	for _, e := range watches {
+		if e.Handle == nil {
+			continue
+		}
+		e.Handle.CheckNow()
+		checked = append(checked, e.Name)
+	}
+	respond(w, http.StatusAccepted, checked)
+}
+
+func status(e watch.Registered) Status {
+	stats := e.Watch.Stats()
+	s := Status{
+		Name:              e.Name,
+		Running:           e.Handle != nil,
+		Checks:            stats.Checks,
+		Changes:           stats.Changes,
+		Errors:            stats.Errors,
+		ConsecutiveErrors: stats.ConsecutiveErrors,
+		LastCheck:         timestamp(stats.LastCheck),
+		LastChange:        timestamp(stats.LastChange),
+	}
+	if id, ok := e.Watch.Target().(watch.Identifier); ok {
+		s.Target = id.Identity()
+	}
+	if stats.LastError != nil {
+		s.LastError = stats.LastError.Error()
+	}
+	if e.Handle != nil {
+		if err := e.Handle.Err(); err != nil {
+			s.Stopped = err.Error()
+		}
+	}
+	return s
+}
+
+func timestamp(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func notFound(w http.ResponseWriter, name string) {
+	http.Error(w, fmt.Sprintf("No watch named %q", name), http.StatusNotFound)
+}
+
+func respond(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,85 @@
+package watchhttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/falun/watch"
+	"github.com/falun/watch/watchhttp"
+	"github.com/falun/watch/watchtest"
+)
+
+func serve(t *testing.T, r *watch.Registry, method, target string, v interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	watchhttp.Handler(r).ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	if v != nil && rec.Code < 300 {
+		if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: unable to decode response: %v", method, target, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestHandlerStatus(t *testing.T) {
+	var r watch.Registry
+	r.Register("b", watch.New(watch.FileTarget("/etc/b.conf", false)), nil)
+	r.Register("a", watch.New(watchtest.NewFake([]byte("content"))), nil)
+
+	var all []watchhttp.Status
+	if code := serve(t, &r, http.MethodGet, "/", &all); code != http.StatusOK {
+		t.Fatalf("GET / = %d, want %d", code, http.StatusOK)
+	}
+	var names []string
+	for _, s := range all {
+		names = append(names, s.Name)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("GET / listed %v, want %v", names, want)
+	}
+
+	var one watchhttp.Status
+	if code := serve(t, &r, http.MethodGet, "/?name=b", &one); code != http.StatusOK {
+		t.Fatalf("GET /?name=b = %d, want %d", code, http.StatusOK)
+	}
+	if one.Name != "b" || one.Target != "/etc/b.conf" || one.Running {
+		t.Errorf("GET /?name=b = %+v, want b's status", one)
+	}
+
+	if code := serve(t, &r, http.MethodGet, "/?name=c", nil); code != http.StatusNotFound {
+		t.Errorf("GET /?name=c = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestHandlerCheckNow(t *testing.T) {
+	var r watch.Registry
+	fake := watchtest.NewFake([]byte("initial"))
+	h := r.Start("running", watch.New(fake), time.Hour, watch.OnIntervalOpts{})
+	defer h.Cancel()
+	r.Register("idle", watch.New(watchtest.NewFake(nil)), nil)
+
+	if code := serve(t, &r, http.MethodPost, "/?name=idle", nil); code != http.StatusConflict {
+		t.Errorf("POST /?name=idle = %d, want %d", code, http.StatusConflict)
+	}
+	if code := serve(t, &r, http.MethodPost, "/?name=missing", nil); code != http.StatusNotFound {
+		t.Errorf("POST /?name=missing = %d, want %d", code, http.StatusNotFound)
+	}
+
+	fake.SetContent([]byte("changed"))
+	var checked []string
+	if code := serve(t, &r, http.MethodPost, "/", &checked); code != http.StatusAccepted {
+		t.Fatalf("POST / = %d, want %d", code, http.StatusAccepted)
+	}
+	if want := []string{"running"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("POST / checked %v, want %v", checked, want)
+	}
+	select {
+	case <-h.C:
+	case <-time.After(5 * time.Second):
+		t.Error("POST didn't make the loop check its target")
+	}
+}
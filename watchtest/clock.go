@@ -0,0 +1,155 @@
+package watchtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/falun/watch"
+)
+
+// Clock is a watch.Clock whose time only moves when Advance is called, so
+// that watch loops using it (c.f. watch.WithClock) can be driven tick by tick
+// in tests. It is safe for concurrent use. The zero value is ready to use
+// and starts at the zero time.
+type Clock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond // c.f. timersChanged
+	now    time.Time
+	timers []*timer
+}
+
+var _ watch.Clock = &Clock{}
+
+// NewClock returns a Clock whose time starts at now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Clock) NewTimer(d time.Duration) watch.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{clock: c, c: make(chan time.Time, 1)}
+	c.schedule(t, d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer that comes due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.active = false
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+	c.timers = pending
+}
+
+// BlockUntil waits until at least n timers are waiting to fire, e.g. for a
+// watch loop to have scheduled its next check before calling Advance.
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.timersChanged().Wait()
+	}
+}
+
+// BlockUntilScheduled waits until a timer is waiting to fire d from now, e.g.
+// for a watch loop to have restarted a timer in reaction to a change when how
+// many timers are waiting doesn't tell.
+func (c *Clock) BlockUntilScheduled(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for !c.scheduled(c.now.Add(d)) {
+		c.timersChanged().Wait()
+	}
+}
+
+// scheduled must be called with mu held.
+func (c *Clock) scheduled(at time.Time) bool {
+	for _, t := range c.timers {
+		if t.at.Equal(at) {
+			return true
+		}
+	}
+	return false
+}
+
+// timersChanged returns the condition signalled when a timer is scheduled,
+// creating it on first use so the zero Clock works. It must be called with mu
+// held.
+func (c *Clock) timersChanged() *sync.Cond {
+	if c.cond == nil {
+		c.cond = sync.NewCond(&c.mu)
+	}
+	return c.cond
+}
+
+// schedule must be called with mu held.
+func (c *Clock) schedule(t *timer, d time.Duration) {
+	t.at = c.now.Add(d)
+	t.active = true
+	c.timers = append(c.timers, t)
+	c.timersChanged().Broadcast()
+}
+
+// unschedule must be called with mu held.
+func (c *Clock) unschedule(t *timer) bool {
+	// drop any value that fired but wasn't received, as time.Timer does
+	select {
+	case <-t.c:
+	default:
+	}
+	if !t.active {
+		return false
+	}
+	t.active = false
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+type timer struct {
+	clock  *Clock
+	c      chan time.Time
+	at     time.Time
+	active bool
+}
+
+func (t *timer) C() <-chan time.Time { return t.c }
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.unschedule(t)
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.clock.unschedule(t)
+	t.clock.schedule(t, d)
+	return active
+}
@@ -0,0 +1,122 @@
+package watchtest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Now()
+	c := NewClock(start)
+
+	early, late, stopped := c.NewTimer(time.Second), c.NewTimer(3*time.Second), c.NewTimer(time.Second)
+	if !stopped.Stop() {
+		t.Error("Stop of a pending timer reported it inactive")
+	}
+
+	c.Advance(2 * time.Second)
+	if now := c.Now(); !now.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("Now() = %v after advancing 2s from %v", now, start)
+	}
+	select {
+	case at := <-early.C():
+		if !at.Equal(start.Add(2 * time.Second)) {
+			t.Errorf("timer fired with %v, want the time it was advanced to", at)
+		}
+	default:
+		t.Error("due timer didn't fire")
+	}
+	for name, timer := range map[string]*timer{"later": late.(*timer), "stopped": stopped.(*timer)} {
+		select {
+		case <-timer.C():
+			t.Errorf("%s timer fired", name)
+		default:
+		}
+	}
+
+	// reset to fire a second from now rather than at its original time
+	if !late.Reset(2 * time.Second) {
+		t.Error("Reset of a pending timer reported it inactive")
+	}
+	c.Advance(time.Second)
+	select {
+	case <-late.C():
+		t.Error("reset timer fired at its original time")
+	default:
+	}
+	c.Advance(time.Second)
+	select {
+	case <-late.C():
+	default:
+		t.Error("reset timer didn't fire")
+	}
+}
+
+func TestClockBlockUntil(t *testing.T) {
+	c := NewClock(time.Now())
+
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		c.BlockUntil(2)
+	}()
+
+	c.NewTimer(time.Second)
+	select {
+	case <-blocked:
+		t.Fatal("BlockUntil(2) returned with one timer")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.NewTimer(time.Second)
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("BlockUntil(2) didn't return with two timers")
+	}
+}
+
+func TestClockBlockUntilScheduled(t *testing.T) {
+	c := NewClock(time.Now())
+	c.NewTimer(time.Second)
+
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		c.BlockUntilScheduled(2 * time.Second)
+	}()
+
+	select {
+	case <-blocked:
+		t.Fatal("BlockUntilScheduled returned without a timer due then")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.NewTimer(2 * time.Second)
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("BlockUntilScheduled didn't return once a timer was due then")
+	}
+}
+
+func TestZeroClock(t *testing.T) {
+	var c Clock
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.BlockUntil(1)
+	}()
+
+	timer := c.NewTimer(time.Second)
+	<-done
+	c.Advance(time.Second)
+	select {
+	case at := <-timer.C():
+		if want := (time.Time{}).Add(time.Second); !at.Equal(want) {
+			t.Errorf("timer fired with %v, want %v", at, want)
+		}
+	default:
+		t.Error("due timer didn't fire")
+	}
+}
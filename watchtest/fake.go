@@ -0,0 +1,75 @@
+// Package watchtest provides test doubles for code built on package watch.
+package watchtest
+
+import (
+	"sync"
+
+	"github.com/falun/watch"
+)
+
+// Fake is a watch.Watched whose content, error, and fail-open policy can be
+// changed at any time, making it easy to drive a watch deterministically in
+// tests. The zero value has empty content and fails closed. It is safe for
+// concurrent use.
+type Fake struct {
+	mu       sync.Mutex
+	content  []byte
+	err      error
+	failOpen bool
+	calls    int
+}
+
+var _ watch.Watched = &Fake{}
+
+// NewFake constructs a Fake with the given initial content.
+func NewFake(content []byte) *Fake {
+	f := &Fake{}
+	f.SetContent(content)
+	return f
+}
+
+// SetContent sets the content returned by Content and clears any error.
+func (f *Fake) SetContent(content []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.content = append([]byte(nil), content...)
+	f.err = nil
+}
+
+// SetError makes Content fail with err until SetContent is called. Passing nil
+// clears the error.
+func (f *Fake) SetError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+// SetFailOpen sets the value reported by FailOpen.
+func (f *Fake) SetFailOpen(failOpen bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failOpen = failOpen
+}
+
+// Calls reports how many times Content has been called.
+func (f *Fake) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *Fake) FailOpen() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failOpen
+}
+
+func (f *Fake) Content() ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return append([]byte(nil), f.content...), nil
+}